@@ -17,6 +17,7 @@ package cmd
 
 import (
 	"errors"
+	"io/ioutil"
 	"net/url"
 	"regexp"
 
@@ -28,6 +29,18 @@ import (
 var repoUser string
 var repoPass string
 var repoRef string
+var repoSSHKey string
+var repoSSHPassphrase string
+var repoSSHAgent bool
+var repoKnownHosts string
+var repoTokenEnv string
+var repoTokenFile string
+var repoTokenStyle string
+var repoTokenUser string
+var repoCredentialHelper bool
+var repoLocal bool
+var repoVerify string
+var repoTrustedKeys []string
 
 // addRepoCmd represents the add command
 var addRepoCmd = &cobra.Command{
@@ -45,7 +58,7 @@ var addRepoCmd = &cobra.Command{
 			PrintError("invalid name given: %s", args[0])
 		}
 
-		if _, err := url.Parse(repoUrl); err != nil {
+		if _, err := url.Parse(repoUrl); err != nil && !repositories.IsSSHURL(repoUrl) {
 			PrintError("invalid URL given: %s", args[1])
 		}
 		return nil
@@ -56,44 +69,131 @@ var addRepoCmd = &cobra.Command{
 		name := args[0]
 		repoUrl := args[1]
 
-		var mods []repositories.RegistryModification
-		authExists, err := repositories.RepoAuthExists(repoUrl)
-		if err != nil {
-			PrintError("unable to check configured auths: %s", err)
-		}
-		if !authExists {
-			newAuth, user, pw, err := RunAuthDialog(repoUser, repoPass)
+		if repoLocal {
+			localUrl, err := repositories.LocalURL(repoUrl)
 			if err != nil {
-				PrintError("unable to display authentication dialog: %s", err)
-			}
-			if newAuth {
-				storemod, err := repositories.StoreRepoAuth(repoUrl, repositories.AuthPair{Username: user, Password: pw})
-				if err != nil {
-					PrintError("unable to store authentication data: %s", err)
-				}
-				mods = append(mods, storemod)
+				PrintError("invalid local directory given: %s", err)
 			}
+			repoUrl = localUrl
 		}
 
+		// Validate the repository first, so no credentials are stored for a repository that can't be added
 		mod, err := repositories.AddRepository(repositories.Repository{
 			Name: name,
 			GitRepository: repositories.GitRepository{
 				URL:    repoUrl,
 				GitRef: repoRef,
 			},
+			Verification: repoVerification(),
 		})
 		if err != nil {
 			PrintError("unable to add repository: %v", err)
 		}
-		mods = append(mods, mod)
+		mods := []repositories.RegistryModification{mod}
+
+		// Local directories are read as they are, without authentication
+		if !repositories.IsLocalURL(repoUrl) {
+			authExists, err := repositories.RepoAuthExists(repoUrl)
+			if err != nil {
+				PrintError("unable to check configured auths: %s", err)
+			}
+			if !authExists && repositories.IsSSHURL(repoUrl) {
+				keyFile, passphrase, err := RunSSHAuthDialog(repoSSHKey, repoSSHPassphrase, repoSSHAgent)
+				if err != nil {
+					PrintError("unable to display authentication dialog: %s", err)
+				}
+				storemod, err := repositories.StoreRepoSSHAuth(repoUrl, repositories.SSHAuth{KeyFile: keyFile, KnownHosts: repoKnownHosts}, passphrase)
+				if err != nil {
+					PrintError("unable to store authentication data: %s", err)
+				}
+				mods = append(mods, storemod)
+			} else if !authExists {
+				authMod := httpAuthModification(repoUrl)
+				if authMod != nil {
+					mods = append(mods, authMod)
+				}
+			}
+		}
+
 		err = repositories.UpdateRegistry(mods...)
 		if err != nil {
+			// Nothing references the stored credentials without the registry update
+			if err := repositories.DiscardAuthCredentials(mods...); err != nil {
+				PrintWarning("unable to remove stored credentials: %s", err)
+			}
 			PrintError("unable to update registry: %s", err)
 		}
 		PrintSuccess("Successfully added repository!")
 	},
 }
 
+// repoVerification reads the trusted keys given via flags, or returns nil if no verification is requested
+func repoVerification() *repositories.Verification {
+	if repoVerify == "" && len(repoTrustedKeys) == 0 {
+		return nil
+	}
+	v := &repositories.Verification{Mode: repositories.VerificationMode(repoVerify)}
+	if v.Mode == "" {
+		v.Mode = repositories.CommitVerificationMode
+	}
+	for _, file := range repoTrustedKeys {
+		key, err := ioutil.ReadFile(file)
+		if err != nil {
+			PrintError("unable to read trusted key: %s", err)
+		}
+		v.TrustedKeys = append(v.TrustedKeys, string(key))
+	}
+	return v
+}
+
+// httpAuthModification stores the auth for an HTTP(S) repository given via flags, or asks for it. Returns nil, if the
+// repository does not need authentication.
+func httpAuthModification(repoUrl string) repositories.RegistryModification {
+	method := noAuthOption
+	switch {
+	case repoTokenEnv != "" || repoTokenFile != "":
+		method = tokenAuthOption
+	case repoCredentialHelper:
+		method = credHelperAuthOption
+	case repoUser != "" || repoPass != "":
+		method = basicAuthOption
+	default:
+		var err error
+		if method, err = RunAuthMethodDialog(); err != nil {
+			PrintError("unable to display authentication dialog: %s", err)
+		}
+	}
+
+	switch method {
+	case tokenAuthOption:
+		ta := repositories.TokenAuth{Env: repoTokenEnv, File: repoTokenFile, Style: repoTokenStyle, Username: repoTokenUser}
+		if ta.Env == "" && ta.File == "" {
+			var err error
+			if ta, err = RunTokenAuthDialog(); err != nil {
+				PrintError("unable to display authentication dialog: %s", err)
+			}
+		}
+		mod, err := repositories.StoreRepoTokenAuth(repoUrl, ta)
+		if err != nil {
+			PrintError("unable to store authentication data: %s", err)
+		}
+		return mod
+	case credHelperAuthOption:
+		return repositories.StoreRepoCredentialHelperAuth(repoUrl)
+	case basicAuthOption:
+		user, pw, err := RunAuthDialog(repoUser, repoPass)
+		if err != nil {
+			PrintError("unable to display authentication dialog: %s", err)
+		}
+		mod, err := repositories.StoreRepoAuth(repoUrl, repositories.AuthPair{Username: user, Password: pw})
+		if err != nil {
+			PrintError("unable to store authentication data: %s", err)
+		}
+		return mod
+	}
+	return nil
+}
+
 func init() {
 	repoCmd.AddCommand(addRepoCmd)
 
@@ -107,5 +207,17 @@ func init() {
 	// is called directly, e.g.:
 	addRepoCmd.Flags().StringVarP(&repoUser, "username", "u", "", "The username for this repository.")
 	addRepoCmd.Flags().StringVarP(&repoPass, "password", "p", "", "The password for this repository.")
-	addRepoCmd.Flags().StringVarP(&repoRef, "ref", "r", "", "The gitref to use for this repository.")
+	addRepoCmd.Flags().StringVarP(&repoRef, "ref", "r", "", "The gitref to use for this repository. Either a branch (refs/heads/NAME), a tag (refs/tags/NAME), a commit SHA or a semver tag range (e.g. ~1.2).")
+	addRepoCmd.Flags().StringVar(&repoSSHKey, "ssh-key", "", "The private key file for this (ssh) repository. Only the path is stored.")
+	addRepoCmd.Flags().StringVar(&repoSSHPassphrase, "ssh-passphrase", "", "The passphrase of the private key.")
+	addRepoCmd.Flags().BoolVar(&repoSSHAgent, "ssh-agent", false, "Use the ssh-agent for this (ssh) repository.")
+	addRepoCmd.Flags().StringVar(&repoTokenEnv, "token-env", "", "The environment variable to read an access token from.")
+	addRepoCmd.Flags().StringVar(&repoTokenFile, "token-file", "", "The file to read an access token from.")
+	addRepoCmd.Flags().StringVar(&repoTokenStyle, "token-style", repositories.BasicTokenAuthStyle, "How to send the token: 'basic' (as password) or 'bearer'.")
+	addRepoCmd.Flags().StringVar(&repoTokenUser, "token-user", "", "The username sent with a 'basic' style token. (default 'x-access-token')")
+	addRepoCmd.Flags().BoolVar(&repoCredentialHelper, "credential-helper", false, "Use the credentials configured for git ('git credential fill').")
+	addRepoCmd.Flags().BoolVar(&repoLocal, "local", false, "Read the repository straight from the local directory URL, instead of cloning it. (same as a 'file://' URL)")
+	addRepoCmd.Flags().StringVar(&repoVerify, "verify", "", "Require the checked out commit to be signed: 'commit' (signed commit) or 'tag' (signed tag pointing at it). (default 'commit' with --trusted-key)")
+	addRepoCmd.Flags().StringSliceVar(&repoTrustedKeys, "trusted-key", nil, "An armored OpenPGP public key file, trusted to sign the repository. (can be repeated)")
+	addRepoCmd.Flags().StringVar(&repoKnownHosts, "known-hosts", "", "The known_hosts file to verify the host key with. (default $SSH_KNOWN_HOSTS or ~/.ssh/known_hosts)")
 }
@@ -2,36 +2,18 @@ package cmd
 
 import (
 	"github.com/AlecAivazis/survey/v2"
+	"github.com/redradrat/kable/pkg/repositories"
 )
 
-// RunAuthDialog runs a dialog to ask for additionally needed auth info. Returns a bool whether auth is required.
-func RunAuthDialog(user, pass string) (bool, string, string, error) {
-
-	if user != "" && pass != "" {
-		return true, user, pass, nil
-	}
-
-	if user == "" && pass == "" {
-		do := true
-		doPrompt := &survey.Confirm{
-			Message: "Does this repository require basic authentication?",
-		}
-		err := survey.AskOne(doPrompt, &do)
-		if err != nil {
-			return false, "", "", err
-		}
-		if !do {
-			return false, "", "", nil
-		}
-	}
-
+// RunAuthDialog asks for the basic auth credentials, that have not been given yet.
+func RunAuthDialog(user, pass string) (string, string, error) {
 	if user == "" {
 		inPrompt := &survey.Input{
 			Message: "Please type your username",
 		}
 		err := survey.AskOne(inPrompt, &user)
 		if err != nil {
-			return false, "", "", err
+			return "", "", err
 		}
 	}
 
@@ -41,9 +23,112 @@ func RunAuthDialog(user, pass string) (bool, string, string, error) {
 		}
 		err := survey.AskOne(pwPrompt, &pass)
 		if err != nil {
-			return false, "", "", err
+			return "", "", err
+		}
+	}
+
+	return user, pass, nil
+}
+
+const (
+	sshAgentOption   = "ssh-agent"
+	sshKeyFileOption = "Key file"
+)
+
+// RunSSHAuthDialog runs a dialog to ask how to authenticate with an SSH repository. Returns the key file (empty for
+// ssh-agent) and its passphrase.
+func RunSSHAuthDialog(keyFile, passphrase string, agent bool) (string, string, error) {
+	if agent {
+		return "", "", nil
+	}
+
+	if keyFile == "" {
+		method := sshAgentOption
+		methodPrompt := &survey.Select{
+			Message: "How do you authenticate with this repository?",
+			Options: []string{sshAgentOption, sshKeyFileOption},
+		}
+		if err := survey.AskOne(methodPrompt, &method); err != nil {
+			return "", "", err
+		}
+		if method == sshAgentOption {
+			return "", "", nil
+		}
+
+		keyPrompt := &survey.Input{
+			Message: "Please type the path to your private key",
+			Default: "~/.ssh/id_rsa",
+		}
+		if err := survey.AskOne(keyPrompt, &keyFile); err != nil {
+			return "", "", err
 		}
 	}
 
-	return true, user, pass, nil
+	if passphrase == "" {
+		pwPrompt := &survey.Password{
+			Message: "Please type the passphrase of your key (leave empty if none)",
+		}
+		if err := survey.AskOne(pwPrompt, &passphrase); err != nil {
+			return "", "", err
+		}
+	}
+
+	return keyFile, passphrase, nil
+}
+
+const (
+	noAuthOption         = "None"
+	basicAuthOption      = "Username/password"
+	tokenAuthOption      = "Access token"
+	credHelperAuthOption = "git credential helper"
+	tokenEnvOption       = "Environment variable"
+	tokenFileOption      = "File"
+)
+
+// RunAuthMethodDialog asks how an HTTP(S) repository authenticates. Returns one of the auth options.
+func RunAuthMethodDialog() (string, error) {
+	method := noAuthOption
+	methodPrompt := &survey.Select{
+		Message: "How does this repository authenticate?",
+		Options: []string{noAuthOption, basicAuthOption, tokenAuthOption, credHelperAuthOption},
+	}
+	if err := survey.AskOne(methodPrompt, &method); err != nil {
+		return "", err
+	}
+	return method, nil
+}
+
+// RunTokenAuthDialog asks where to read an access token from, and how to pass it on
+func RunTokenAuthDialog() (repositories.TokenAuth, error) {
+	ta := repositories.TokenAuth{}
+	source := tokenEnvOption
+	sourcePrompt := &survey.Select{
+		Message: "Where should the token be read from?",
+		Options: []string{tokenEnvOption, tokenFileOption},
+	}
+	if err := survey.AskOne(sourcePrompt, &source); err != nil {
+		return ta, err
+	}
+
+	if source == tokenEnvOption {
+		envPrompt := &survey.Input{Message: "Please type the name of the environment variable"}
+		if err := survey.AskOne(envPrompt, &ta.Env, survey.WithValidator(survey.Required)); err != nil {
+			return ta, err
+		}
+	} else {
+		filePrompt := &survey.Input{Message: "Please type the path to the token file"}
+		if err := survey.AskOne(filePrompt, &ta.File, survey.WithValidator(survey.Required)); err != nil {
+			return ta, err
+		}
+	}
+
+	stylePrompt := &survey.Select{
+		Message: "How should the token be sent?",
+		Options: []string{repositories.BasicTokenAuthStyle, repositories.BearerTokenAuthStyle},
+		Help:    "basic: as password of user 'x-access-token' (e.g. GitHub, GitLab deploy tokens), bearer: as 'Authorization: Bearer' header",
+	}
+	if err := survey.AskOne(stylePrompt, &ta.Style); err != nil {
+		return ta, err
+	}
+	return ta, nil
 }
@@ -0,0 +1,131 @@
+/*
+Copyright © 2020 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/redradrat/kable/pkg/concepts"
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	describeTextOutput = "text"
+	describeJSONOutput = "json"
+	describeYAMLOutput = "yaml"
+)
+
+var describeOutput string
+
+// describeCmd represents the describe command
+var describeCmd = &cobra.Command{
+	Use:   "describe CONCEPT",
+	Short: "Show the metadata, origin, documentation and inputs of a concept",
+	Example: `
+kable describe my/concept@myrepo
+kable describe my/concept@myrepo -o yaml
+`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if len(args) != 1 {
+			return errors.New("requires exactly ONE argument")
+		}
+		if !concepts.IsValidConceptIdentifier(args[0]) {
+			PrintError("invalid concept identifier given: %s", args[0])
+		}
+		return nil
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		initConfig()
+		if describeOutput != describeTextOutput && describeOutput != describeJSONOutput && describeOutput != describeYAMLOutput {
+			PrintError("unsupported output format '%s'", describeOutput)
+		}
+
+		desc, err := concepts.DescribeRepoConcept(concepts.ConceptIdentifier(args[0]))
+		if err != nil {
+			PrintError("unable to describe concept: %s", err)
+		}
+
+		switch describeOutput {
+		case describeJSONOutput:
+			out, err := json.MarshalIndent(desc, "", "  ")
+			if err != nil {
+				PrintError("unable to marshal concept description: %s", err)
+			}
+			fmt.Println(string(out))
+		case describeYAMLOutput:
+			out, err := yaml.Marshal(desc)
+			if err != nil {
+				PrintError("unable to marshal concept description: %s", err)
+			}
+			fmt.Print(string(out))
+		default:
+			printDescription(desc)
+		}
+	},
+}
+
+func printDescription(desc *concepts.ConceptDescription) {
+	fields := [][]string{
+		{"Concept", desc.ID.String()},
+		{"Name", desc.Metadata.Name},
+		{"Description", desc.Metadata.Description},
+		{"Type", desc.Type.String()},
+		{"Maintainer", desc.Metadata.Maintainer.String()},
+		{"Tags", strings.Join(desc.Metadata.Tags, ", ")},
+	}
+	if desc.Origin != nil {
+		ref := desc.Origin.Ref
+		if ref == "" {
+			ref = "(default branch)"
+		}
+		fields = append(fields, []string{"Repository", desc.Origin.Repository}, []string{"Ref", ref}, []string{"Commit", desc.Origin.Commit})
+	}
+	for _, f := range fields {
+		if f[1] == "" {
+			f[1] = "-"
+		}
+		PrintMsg("%-12s %s", f[0]+":", f[1])
+	}
+
+	PrintMsg("")
+	if len(desc.Inputs) == 0 {
+		PrintMsg("This concept has no inputs.")
+	} else {
+		var lines [][]string
+		for _, in := range desc.Inputs {
+			required := "optional"
+			if in.Mandatory {
+				required = "mandatory"
+			}
+			lines = append(lines, []string{in.ID, in.Type.String(), required, in.Description, in.Example, strings.Join(in.Options, ", ")})
+		}
+		PrintTable([]string{"Input", "Type", "Required", "Description", "Example", "Options"}, lines...)
+	}
+
+	if desc.Readme != "" {
+		PrintMsg("\n%s", desc.Readme)
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(describeCmd)
+
+	describeCmd.Flags().StringVarP(&describeOutput, "output", "o", describeTextOutput, "The output format ("+strings.Join([]string{describeTextOutput, describeJSONOutput, describeYAMLOutput}, ", ")+")")
+}
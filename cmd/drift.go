@@ -0,0 +1,107 @@
+/*
+Copyright © 2020 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/redradrat/kable/pkg/concepts"
+	"github.com/spf13/cobra"
+)
+
+const (
+	driftTextOutput = "text"
+	driftJSONOutput = "json"
+)
+
+var driftOutput string
+
+// driftCmd represents the drift command
+var driftCmd = &cobra.Command{
+	Use:   "drift [ROOT]",
+	Short: "Report rendered instances, that no longer match a fresh render",
+	Long: `Finds every renderinfo.json below ROOT, re-renders each instance in memory, and classifies it as
+up-to-date, concept-changed, hand-edited or failing. Exits with 1, if any instance is not up to date.`,
+	Example: `
+kable drift clusters/
+kable drift clusters/ -o json
+`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		initConfig()
+		root := "."
+		if len(args) == 1 {
+			root = args[0]
+		}
+		if driftOutput != driftTextOutput && driftOutput != driftJSONOutput {
+			PrintError("unsupported output format '%s'", driftOutput)
+		}
+
+		report, err := concepts.DetectDrift(root)
+		if err != nil {
+			PrintError("unable to detect drift: %s", err)
+		}
+
+		if driftOutput == driftJSONOutput {
+			out, err := json.MarshalIndent(report, "", "  ")
+			if err != nil {
+				PrintError("unable to marshal report: %s", err)
+			}
+			fmt.Println(string(out))
+		} else {
+			var lines [][]string
+			for _, res := range report.Results {
+				details := res.Error
+				if len(res.Files) != 0 {
+					details = summarizeFiles(res.Files, 3)
+				}
+				lines = append(lines, []string{res.Path, res.Concept, driftStatusString(res.Status), details})
+			}
+			PrintTable([]string{"Path", "Concept", "Status", "Details"}, lines...)
+		}
+
+		if drifted := report.Drifted(); len(drifted) != 0 {
+			if driftOutput == driftTextOutput {
+				PrintError("%d of %d instances drifted", len(drifted), len(report.Results))
+			}
+			os.Exit(1)
+		}
+		if driftOutput == driftTextOutput {
+			PrintSuccess("All %d instances are up to date!", len(report.Results))
+		}
+	},
+}
+
+func driftStatusString(status concepts.DriftStatus) string {
+	switch status {
+	case concepts.DriftUpToDate:
+		return color.GreenString(string(status))
+	case concepts.DriftFailing:
+		return color.RedString(string(status))
+	default:
+		return color.YellowString(string(status))
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(driftCmd)
+
+	driftCmd.Flags().StringVarP(&driftOutput, "output", "o", driftTextOutput, "The report format ("+strings.Join([]string{driftTextOutput, driftJSONOutput}, ", ")+")")
+}
@@ -0,0 +1,85 @@
+/*
+Copyright © 2020 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"errors"
+	"os"
+
+	"github.com/redradrat/kable/pkg/repositories"
+	"github.com/spf13/cobra"
+)
+
+var exportOutput string
+
+// exportRepoCmd represents the repo export command
+var exportRepoCmd = &cobra.Command{
+	Use:   "export REPO",
+	Short: "Pack a repository into a bundle, to be imported in disconnected environments",
+	Long: `Packs the cached checkout of the repository at its current commit, along with its registry metadata and the 
+checksums of all files, into a gzipped tarball. Transfer it along with the printed digest, and register it with
+'kable repo import' on the other side.`,
+	Example: `
+kable repo export myrepo -o myrepo.tgz
+`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if len(args) != 1 {
+			return errors.New("requires exactly ONE argument")
+		}
+		return nil
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		initConfig()
+		repo, err := repositories.GetRepository(args[0])
+		if err != nil {
+			PrintError("unable to get repository: %s", err)
+		}
+		out := exportOutput
+		if out == "" {
+			out = repo.Name + ".tgz"
+		}
+
+		f, err := os.Create(out)
+		if err != nil {
+			PrintError("unable to create bundle: %s", err)
+		}
+		manifest, err := repositories.ExportBundle(repo, f)
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			os.Remove(out)
+			PrintError("unable to export repository: %s", err)
+		}
+		digest, err := repositories.BundleDigest(out)
+		if err != nil {
+			PrintError("unable to compute bundle digest: %s", err)
+		}
+
+		commit := manifest.Repository.Commit
+		if commit == "" {
+			commit = "working tree"
+		}
+		PrintSuccess("Exported '%s' at %s (%d files) to '%s'!", repo.Name, commit, len(manifest.Files), out)
+		PrintMsg("SHA256: %s", digest)
+	},
+}
+
+func init() {
+	repoCmd.AddCommand(exportRepoCmd)
+
+	exportRepoCmd.Flags().StringVarP(&exportOutput, "output", "o", "", "The bundle file to write (default: REPO.tgz)")
+}
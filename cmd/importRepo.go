@@ -0,0 +1,83 @@
+/*
+Copyright © 2020 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"errors"
+	"os"
+	"strings"
+
+	"github.com/redradrat/kable/pkg/repositories"
+	"github.com/spf13/cobra"
+)
+
+var importName string
+var importDigest string
+
+// importRepoCmd represents the repo import command
+var importRepoCmd = &cobra.Command{
+	Use:   "import BUNDLE",
+	Short: "Register a repository bundle, exported with 'kable repo export'",
+	Long: `Unpacks the bundle into ~/.kable/bundles and registers it as local repository. Every file is checked against
+the checksums of the bundle, and with --sha256 the bundle itself against the digest printed on export. Importing a
+newer bundle of the same repository replaces the previous one.`,
+	Example: `
+kable repo import myrepo.tgz --sha256 3f1c...
+`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if len(args) != 1 {
+			return errors.New("requires exactly ONE argument")
+		}
+		return nil
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		initConfig()
+		if importDigest != "" {
+			digest, err := repositories.BundleDigest(args[0])
+			if err != nil {
+				PrintError("unable to compute bundle digest: %s", err)
+			}
+			if !strings.EqualFold(digest, importDigest) {
+				PrintError("bundle digest %s does not match the expected %s", digest, importDigest)
+			}
+		}
+
+		f, err := os.Open(args[0])
+		if err != nil {
+			PrintError("unable to open bundle: %s", err)
+		}
+		defer f.Close()
+		manifest, mod, err := repositories.ImportBundle(f, importName)
+		if err != nil {
+			PrintError("unable to import bundle: %s", err)
+		}
+		if err := repositories.UpdateRegistry(mod); err != nil {
+			PrintError("unable to update registry: %s", err)
+		}
+		name := importName
+		if name == "" {
+			name = manifest.Repository.Name
+		}
+		PrintSuccess("Imported '%s' (%s at %s) as '%s'!", manifest.Repository.Name, manifest.Repository.URL, manifest.Repository.Commit, name)
+	},
+}
+
+func init() {
+	repoCmd.AddCommand(importRepoCmd)
+
+	importRepoCmd.Flags().StringVar(&importName, "name", "", "The name to register the repository as (default: its name in the bundle)")
+	importRepoCmd.Flags().StringVar(&importDigest, "sha256", "", "The expected SHA256 digest of the bundle")
+}
@@ -0,0 +1,56 @@
+/*
+Copyright © 2020 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"errors"
+
+	"github.com/redradrat/kable/pkg/concepts"
+	"github.com/redradrat/kable/pkg/repositories"
+	"github.com/spf13/cobra"
+)
+
+// indexRepoCmd represents the repo index command
+var indexRepoCmd = &cobra.Command{
+	Use:   "index [DIR]",
+	Short: "Regenerate the kable.json of a repository from the concepts found in it",
+	Long: `Walks the repository directory (default: current directory) for concepts, and writes their paths and 
+metadata into its kable.json. The include/exclude globs of an existing kable.json are respected.`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if len(args) > 1 {
+			return errors.New("requires at most ONE argument")
+		}
+		return nil
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		dir := "."
+		if len(args) == 1 {
+			dir = args[0]
+		}
+		idx, err := concepts.IndexRepository(dir)
+		if err != nil {
+			PrintError("unable to index repository: %s", err)
+		}
+		if err := repositories.WriteRepoIndex(dir, *idx); err != nil {
+			PrintError("unable to write repository index: %s", err)
+		}
+		PrintSuccess("Indexed %d concepts!", len(idx.ConceptEntries))
+	},
+}
+
+func init() {
+	repoCmd.AddCommand(indexRepoCmd)
+}
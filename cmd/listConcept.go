@@ -26,20 +26,16 @@ var listConceptsCmd = &cobra.Command{
 	Short: "List all available concepts",
 	Run: func(cmd *cobra.Command, args []string) {
 		initConfig()
-		cis, err := concepts.ListConcepts()
+		ics, err := concepts.ListIndexedConcepts()
 		if err != nil {
 			PrintError("unable to list concepts: %s", err)
 		}
 		var outList [][]string
-		for _, ci := range cis {
-			c, err := concepts.GetRepoConcept(ci)
-			if err != nil {
-				PrintError("error getting concept '%s': %s", ci.String(), err)
-			}
+		for _, ic := range ics {
 			outList = append(outList, []string{
-				ci.Concept(),
-				ci.Repo(),
-				c.Meta.Maintainer.String(),
+				ic.ID.Concept(),
+				ic.ID.Repo(),
+				ic.Meta.Maintainer.String(),
 			})
 		}
 		PrintTable([]string{"ID", "Repository", "Maintainer"}, outList...)
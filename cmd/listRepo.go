@@ -16,6 +16,8 @@ limitations under the License.
 package cmd
 
 import (
+	"strings"
+
 	"github.com/redradrat/kable/pkg/repositories"
 	"github.com/spf13/cobra"
 )
@@ -32,13 +34,26 @@ var listReposCmd = &cobra.Command{
 		}
 		var repoSlices [][]string
 		for _, repo := range repos {
-			repoSlices = append(repoSlices, []string{repo.Name, repo.URL, repo.GitRef})
+			repoSlices = append(repoSlices, []string{repo.Name, repo.URL, repo.GitRef, checkedOutRef(repo)})
 		}
 
-		PrintTable([]string{"ID", "URL", "Ref"}, repoSlices...)
+		PrintTable([]string{"ID", "URL", "Ref", "Commit"}, repoSlices...)
 	},
 }
 
+// checkedOutRef describes the commit the repository is currently resolved to, with its tags
+func checkedOutRef(repo repositories.Repository) string {
+	commit, tags, err := repo.CheckedOutRef()
+	if err != nil || commit == "" {
+		return "-"
+	}
+	out := commit[:7]
+	if len(tags) > 0 {
+		out += " (" + strings.Join(tags, ", ") + ")"
+	}
+	return out
+}
+
 func init() {
 	repoCmd.AddCommand(listReposCmd)
 
@@ -0,0 +1,50 @@
+/*
+Copyright © 2020 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"github.com/redradrat/kable/pkg/repositories"
+	"github.com/spf13/cobra"
+)
+
+// migrateAuthsCmd represents the migrate-auths command
+var migrateAuthsCmd = &cobra.Command{
+	Use:   "migrate-auths",
+	Short: "Move stored repository credentials into the credential store",
+	Run: func(cmd *cobra.Command, args []string) {
+		initConfig()
+		PrintMsg("Migrating repository credentials...")
+		mod, urls, err := repositories.MigrateAuths()
+		if err != nil {
+			PrintError("unable to migrate credentials: %s", err)
+		}
+		if len(urls) == 0 {
+			PrintSuccess("No credentials to migrate!")
+			return
+		}
+		if err := repositories.UpdateRegistry(mod); err != nil {
+			PrintError("unable to update registry: %s", err)
+		}
+		for _, url := range urls {
+			PrintMsg("Migrated '%s'", url)
+		}
+		PrintSuccess("Successfully migrated %d credentials!", len(urls))
+	},
+}
+
+func init() {
+	repoCmd.AddCommand(migrateAuthsCmd)
+}
@@ -0,0 +1,65 @@
+/*
+Copyright © 2020 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"github.com/redradrat/kable/pkg/concepts"
+	"github.com/spf13/cobra"
+)
+
+var migrateDryRun bool
+
+// migrateRenderInfoCmd represents the renderinfo migrate command
+var migrateRenderInfoCmd = &cobra.Command{
+	Use:   "migrate [ROOT]",
+	Short: "Upgrade all renderinfo files below ROOT to the latest version",
+	Example: `
+kable renderinfo migrate
+kable renderinfo migrate clusters/ --dry-run
+`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		root := "."
+		if len(args) == 1 {
+			root = args[0]
+		}
+
+		migrated, err := concepts.MigrateRenderInfoTree(root, migrateDryRun)
+		for _, path := range migrated {
+			if migrateDryRun {
+				PrintMsg("Would migrate '%s'", path)
+			} else {
+				PrintMsg("Migrated '%s'", path)
+			}
+		}
+		if err != nil {
+			PrintError("unable to migrate renderinfo files: %s", err)
+		}
+		if len(migrated) == 0 {
+			PrintSuccess("All renderinfo files are up to date!")
+			return
+		}
+		if !migrateDryRun {
+			PrintSuccess("Successfully migrated %d renderinfo files!", len(migrated))
+		}
+	},
+}
+
+func init() {
+	renderInfoCmd.AddCommand(migrateRenderInfoCmd)
+
+	migrateRenderInfoCmd.Flags().BoolVar(&migrateDryRun, "dry-run", false, "Only list the files that would be migrated")
+}
@@ -0,0 +1,96 @@
+/*
+Copyright © 2020 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"errors"
+
+	"github.com/redradrat/kable/pkg/concepts"
+	"github.com/redradrat/kable/pkg/gitops"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+const (
+	publishRepositoryKey = "publishRepository"
+	publishBranchKey     = "publishBranch"
+)
+
+var publishRepo string
+var publishBranch string
+var publishPath string
+
+// publishCmd represents the publish command
+var publishCmd = &cobra.Command{
+	Use:   "publish [DIR]",
+	Short: "Commit and push a rendered output directory to a GitOps repository",
+	Example: `
+kable publish out/ --repo https://github.com/me/gitops.git --path clusters/prod/grafana
+kable publish out/ --repo /srv/git/gitops.git --branch deploy --path grafana
+`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if len(args) != 1 {
+			return errors.New("requires exactly ONE argument")
+		}
+		return nil
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		initConfig()
+		render, err := concepts.ReadRender(args[0])
+		if err != nil {
+			PrintError("unable to read rendered output: %s", err)
+		}
+		publishRender(render)
+	},
+}
+
+// publishRender publishes the given render according to the publish flags, falling back to the settings
+func publishRender(render *concepts.Render) {
+	opts := gitops.PublishOpts{URL: publishRepo, Branch: publishBranch, Path: publishPath}
+	if opts.URL == "" {
+		opts.URL = viper.GetString(publishRepositoryKey)
+	}
+	if opts.Branch == "" {
+		opts.Branch = viper.GetString(publishBranchKey)
+	}
+	if opts.URL == "" {
+		PrintError("no target repository given (use --repo, or set '%s' in the settings)", publishRepositoryKey)
+	}
+
+	PrintMsg("Publishing to '%s'...", opts.URL)
+	res, err := gitops.Publish(render, opts)
+	if err != nil {
+		PrintError("unable to publish render: %s", err)
+	}
+	if !res.Changed {
+		PrintSuccess("Nothing to publish, '%s' is up to date!", opts.Path)
+		return
+	}
+	PrintSuccess("Successfully published render as %s!", res.Commit)
+}
+
+func addPublishFlags(cmd *cobra.Command, prefix string) {
+	cmd.Flags().StringVar(&publishRepo, prefix+"repo", "", "The GitOps repository to publish to (default from setting '"+publishRepositoryKey+"')")
+	cmd.Flags().StringVar(&publishBranch, prefix+"branch", "", "The branch to publish to (default from setting '"+publishBranchKey+"', or '"+gitops.DefaultBranch+"')")
+}
+
+func init() {
+	rootCmd.AddCommand(publishCmd)
+
+	addPublishFlags(publishCmd, "")
+	publishCmd.Flags().StringVar(&publishPath, "path", "", "The path within the repository to write the render to")
+	_ = publishCmd.MarkFlagRequired("path")
+}
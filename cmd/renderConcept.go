@@ -21,6 +21,8 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
 
 	"github.com/redradrat/kable/pkg/concepts"
 
@@ -33,6 +35,10 @@ var local bool
 var single bool
 var renderinfo string
 var printOnly bool
+var environments []string
+var valuesFile string
+var watch bool
+var noCache bool
 
 // renderConceptCmd represents the create command
 var renderConceptCmd = &cobra.Command{
@@ -48,6 +54,12 @@ kable render -l . -o out/
 		}
 
 		conceptIdentifier := args[0]
+		if publishPath != "" && (watch || printOnly || renderinfo != "") {
+			PrintError("publishing cannot be combined with watch mode, print mode or an external renderinfo")
+		}
+		if watch && (!local || printOnly) {
+			PrintError("watch mode can only be used with a local concept, without print mode")
+		}
 		if local {
 			f, err := os.Stat(conceptIdentifier)
 			if os.IsNotExist(err) {
@@ -89,78 +101,31 @@ kable render -l . -o out/
 
 		// check if existing RenderInfo exists, or run dialog to get values for concept inputs
 		var avs *concepts.RenderValues
-		existingRenderInfo := true
-		outdatedValues := false
-		var ri *concepts.RenderInfoV1
-		if renderinfo != "" {
-			ri, err = concepts.ParseRenderInfoV1FromFile(renderinfo)
-		} else {
-			PrintMsg("Checking for existing renderinfo.json in output dir...")
-			ri, err = concepts.ParseRenderInfoV1FromFile(filepath.Join(outpath, concepts.ConceptRenderFileName))
-		}
-		if err != nil {
-			if os.IsNotExist(err) {
-				PrintMsg(fmt.Sprintf("No existing renderinfo.json detected."))
-				existingRenderInfo = false
-			} else {
-				PrintError("error parsing existing renderinfo: %s", err)
-			}
+		var layers concepts.ValueLayers
+		if len(environments) != 0 {
+			avs, layers = resolveEnvironmentValues(cpt)
 		} else {
-			PrintMsg(fmt.Sprintf("Existing renderinfo.json detected at %s/renderinfo.json.", outpath))
-		}
-
-		// Ask for values if renderinfo does not exist
-		if existingRenderInfo {
-			vals := *ri.Values
-			for k, _ := range cpt.Inputs.Mandatory {
-				if _, ok := vals[k]; !ok {
-					outdatedValues = true
-				}
-			}
-			avs = &vals
-
-			if outdatedValues {
-				PrintError("Detected outdated values in renderinfo.json")
-			}
-		} else {
-			if printOnly {
-				PrintError("Cannot use print mode without preexisting renderinfo.json")
-			}
-			avs, err = NewInputDialog(cpt.Inputs).RunInputDialog()
-			if err != nil {
-				PrintError("error processing concept inputs: %s", err)
-			}
+			avs = existingOrNewValues(cpt)
 		}
 
 		// Now let's render our app
 		PrintMsg("Rendering concept...")
-		var bundle *concepts.Render
-		bundle, err = concepts.RenderConcept(conceptIdentifier.String(), avs, concepts.TargetType(conceptRenderTargetType), concepts.RenderOpts{Single: single, Local: local, WriteRenderInfo: renderinfo == ""})
+		bundle, err := renderAndWrite(conceptIdentifier.String(), avs, layers)
 		if err != nil {
-			PrintError("unable to render concept: %s", err)
-		}
-
-		if !printOnly {
-			_, err := ioutil.ReadDir(outpath)
-			if err != nil && !os.IsNotExist(err) {
-				PrintError("unable to read directory '%s' for rendering: %s", outpath, err)
-			}
-		}
-
-		if printOnly {
-			fmt.Print(bundle.PrintFiles())
-		} else {
-			if renderinfo == "" {
-				if err := bundle.WriteInfo(outpath); err != nil {
-					PrintError("unable to write renderinfo to file system: %s", err)
-				}
-			}
-			if err := bundle.WriteFiles(outpath); err != nil {
-				PrintError("unable to write rendered concept to file system: %s", err)
+			if !watch {
+				PrintError("%s", err)
 			}
+			PrintWarning("%s", err)
+		} else if !printOnly {
 			PrintSuccess("Successfully created concept!")
+			if publishPath != "" {
+				publishRender(bundle)
+			}
 		}
 
+		if watch {
+			watchConcept(conceptIdentifier.String(), avs, layers)
+		}
 	},
 }
 
@@ -181,4 +146,152 @@ func init() {
 	renderConceptCmd.Flags().StringVarP(&renderinfo, "renderinfo", "r", "", "Path to an existing renderinfo to use. (skips writing renderinfo.json)")
 	renderConceptCmd.Flags().StringVarP(&conceptRenderTargetType, "targetType", "t", string(concepts.YamlTargetType), "The target format, this concept will be rendered as")
 	renderConceptCmd.Flags().BoolVarP(&printOnly, "print", "p", false, "Runs silent and prints manifests to stdout. (renderinfo.json needs to exist)")
+	renderConceptCmd.Flags().StringSliceVarP(&environments, "env", "e", nil, "The environment(s) to resolve values for from the values file, applied in order on top of the base values")
+	renderConceptCmd.Flags().BoolVar(&noCache, "no-cache", false, "Always evaluate the concept, instead of using the render cache")
+	renderConceptCmd.Flags().BoolVarP(&watch, "watch", "w", false, "Watch the local concept for changes and re-render")
+	renderConceptCmd.Flags().StringVar(&publishPath, "publish", "", "Publish the render to this path within the GitOps repository")
+	addPublishFlags(renderConceptCmd, "publish-")
+	renderConceptCmd.Flags().StringVar(&valuesFile, "values-file", concepts.ValuesFileName, "The values file defining base values and environment overlays")
+}
+
+// resolveEnvironmentValues resolves the values for the given environments from the values file
+func resolveEnvironmentValues(cpt *concepts.Concept) (*concepts.RenderValues, concepts.ValueLayers) {
+	PrintMsg("Resolving values for environment(s) %s from '%s'...", strings.Join(environments, ", "), valuesFile)
+	overlays, err := concepts.ParseValuesOverlaysFromFile(valuesFile)
+	if err != nil {
+		PrintError("unable to read values file: %s", err)
+	}
+	avs, layers, err := overlays.Resolve(environments...)
+	if err != nil {
+		PrintError("unable to resolve values: %s", err)
+	}
+	for k := range cpt.Inputs.Mandatory {
+		if _, ok := (*avs)[k]; !ok {
+			PrintError("mandatory value '%s' is not defined by any layer", k)
+		}
+	}
+	return avs, layers
+}
+
+// existingOrNewValues reuses the values of an existing renderinfo, or asks for them via dialog
+func existingOrNewValues(cpt *concepts.Concept) *concepts.RenderValues {
+	var avs *concepts.RenderValues
+	var err error
+	existingRenderInfo := true
+	outdatedValues := false
+	var ri *concepts.RenderInfoV2
+	if renderinfo != "" {
+		ri, err = concepts.ParseRenderInfoFromFile(renderinfo)
+	} else {
+		PrintMsg("Checking for existing renderinfo.json in output dir...")
+		ri, err = concepts.ParseRenderInfoFromFile(filepath.Join(outpath, concepts.ConceptRenderFileName))
+	}
+	if err != nil {
+		if os.IsNotExist(err) {
+			PrintMsg(fmt.Sprintf("No existing renderinfo.json detected."))
+			existingRenderInfo = false
+		} else {
+			PrintError("error parsing existing renderinfo: %s", err)
+		}
+	} else {
+		PrintMsg(fmt.Sprintf("Existing renderinfo.json detected at %s/renderinfo.json.", outpath))
+	}
+
+	// Ask for values if renderinfo does not exist
+	if existingRenderInfo {
+		avs = ri.RenderValues()
+		// Values are migrated during rendering, so only check them against the migrated copy
+		migrated, _ := cpt.Migrations.Apply(avs)
+		for k, _ := range cpt.Inputs.Mandatory {
+			if _, ok := (*migrated)[k]; !ok {
+				outdatedValues = true
+			}
+		}
+
+		if outdatedValues {
+			PrintError("Detected outdated values in renderinfo.json")
+		}
+	} else {
+		if printOnly {
+			PrintError("Cannot use print mode without preexisting renderinfo.json")
+		}
+		avs, err = NewInputDialog(cpt.Inputs).RunInputDialog()
+		if err != nil {
+			PrintError("error processing concept inputs: %s", err)
+		}
+	}
+	return avs
+}
+
+// renderAndWrite renders the concept with the given values, and writes the result to the output dir, or prints it
+func renderAndWrite(id string, avs *concepts.RenderValues, layers concepts.ValueLayers) (*concepts.Render, error) {
+	bundle, err := concepts.RenderConcept(id, avs, concepts.TargetType(conceptRenderTargetType), concepts.RenderOpts{Single: single, Local: local, WriteRenderInfo: renderinfo == "", NoCache: noCache, Environments: environments, Layers: layers, HookOutput: os.Stderr})
+	if err != nil {
+		return nil, fmt.Errorf("unable to render concept: %s", err)
+	}
+
+	if printOnly {
+		fmt.Print(bundle.PrintFiles())
+		return bundle, nil
+	}
+
+	if m := bundle.Migrations; m != nil {
+		for _, from := range getSortedStringMapKeys(m.Renamed) {
+			PrintMsg("Migrated value '%s' to '%s'", from, m.Renamed[from])
+		}
+		for _, k := range m.Removed {
+			PrintMsg("Removed value '%s'", k)
+		}
+		for _, k := range getSortedStringMapKeys(m.Mapped) {
+			PrintMsg("Migrated value '%s': %s", k, m.Mapped[k])
+		}
+	}
+
+	if _, err := ioutil.ReadDir(outpath); err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("unable to read directory '%s' for rendering: %s", outpath, err)
+	}
+	if renderinfo == "" {
+		if err := bundle.WriteInfo(outpath); err != nil {
+			return nil, fmt.Errorf("unable to write renderinfo to file system: %s", err)
+		}
+	}
+	if err := bundle.WriteFiles(outpath); err != nil {
+		return nil, fmt.Errorf("unable to write rendered concept to file system: %s", err)
+	}
+	return bundle, nil
+}
+
+// watchConcept re-renders the local concept at path on every change, until interrupted. Errors are shown inline.
+func watchConcept(path string, avs *concepts.RenderValues, layers concepts.ValueLayers) {
+	PrintMsg("Watching '%s' for changes... (Ctrl+C to stop)", path)
+	err := concepts.WatchConcept(path, concepts.DefaultWatchDebounce, nil, func(changed []string) {
+		PrintMsg("Changed: %s", summarizeFiles(changed, 3))
+		if _, err := renderAndWrite(path, avs, layers); err != nil {
+			PrintWarning("%s", err)
+			return
+		}
+		PrintSuccess("Re-rendered concept!")
+	}, func(err error) {
+		PrintWarning("error while watching: %s", err)
+	})
+	if err != nil {
+		PrintError("unable to watch concept: %s", err)
+	}
+}
+
+func getSortedStringMapKeys(values map[string]string) []string {
+	var keys []string
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// summarizeFiles lists up to max files, followed by the count of the remaining ones
+func summarizeFiles(files []string, max int) string {
+	if len(files) <= max {
+		return strings.Join(files, ", ")
+	}
+	return fmt.Sprintf("%s (+%d more)", strings.Join(files[:max], ", "), len(files)-max)
 }
@@ -10,6 +10,7 @@ import (
 	"os"
 	"path/filepath"
 
+	"github.com/redradrat/kable/pkg/concepts"
 	"github.com/redradrat/kable/pkg/repositories"
 
 	"github.com/spf13/viper"
@@ -17,6 +18,8 @@ import (
 	"github.com/spf13/cobra"
 )
 
+const renderCacheMaxSizeKey = "renderCacheMaxSize"
+
 // rootCmd represents the base command when called without any subcommands
 var rootCmd = &cobra.Command{}
 var cfgFile string
@@ -32,6 +35,8 @@ func Execute() {
 
 func init() {
 	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is $HOME/.kable/userconfig.json)")
+	rootCmd.PersistentFlags().Bool("offline", false, "Never access the network, only use cached repositories (default from setting '"+repositories.OfflineKey+"')")
+	viper.BindPFlag(repositories.OfflineKey, rootCmd.PersistentFlags().Lookup("offline"))
 	//cobra.OnInitialize(initConfig)
 	bindFlags(rootCmd, viper.GetViper())
 }
@@ -62,6 +67,16 @@ func initConfig() {
 	viper.SetEnvPrefix("KABLE")
 
 	viper.AutomaticEnv()
+
+	if err := viper.ReadInConfig(); err != nil {
+		if _, ok := err.(viper.ConfigFileNotFoundError); !ok {
+			PrintError("unable to read settings: %s", err)
+		}
+	}
+
+	if viper.IsSet(renderCacheMaxSizeKey) {
+		concepts.RenderCacheMaxSize = viper.GetInt64(renderCacheMaxSizeKey)
+	}
 }
 
 // Bind each cobra flag to its associated viper configuration (config file and environment variable)
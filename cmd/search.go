@@ -0,0 +1,76 @@
+/*
+Copyright © 2020 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"strings"
+
+	"github.com/redradrat/kable/pkg/concepts"
+	"github.com/spf13/cobra"
+)
+
+var searchTags []string
+var searchMaintainer string
+var searchRepo string
+var searchType string
+
+// searchCmd represents the search command
+var searchCmd = &cobra.Command{
+	Use:   "search [TEXT]...",
+	Short: "Search concepts by text, tags, maintainer, repository and type",
+	Example: `
+kable search grafana
+kable search monitoring --tag observability --repo demo
+`,
+	Run: func(cmd *cobra.Command, args []string) {
+		initConfig()
+		results, err := concepts.SearchConcepts(concepts.SearchQuery{
+			Text:       strings.Join(args, " "),
+			Tags:       searchTags,
+			Maintainer: searchMaintainer,
+			Repository: searchRepo,
+			Type:       concepts.ConceptType(searchType),
+		})
+		if err != nil {
+			PrintError("unable to search concepts: %s", err)
+		}
+		if len(results) == 0 {
+			PrintWarning("No concepts found.")
+			return
+		}
+
+		var lines [][]string
+		for _, res := range results {
+			lines = append(lines, []string{
+				res.ID.Concept(),
+				res.ID.Repo(),
+				strings.Join(res.Concept.Meta.Tags, ", "),
+				res.Concept.Meta.Maintainer.String(),
+				res.Concept.Meta.Description,
+			})
+		}
+		PrintTable([]string{"ID", "Repository", "Tags", "Maintainer", "Description"}, lines...)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(searchCmd)
+
+	searchCmd.Flags().StringSliceVarP(&searchTags, "tag", "t", nil, "Only concepts with this tag (can be repeated, all have to match)")
+	searchCmd.Flags().StringVarP(&searchMaintainer, "maintainer", "m", "", "Only concepts whose maintainer name or email contains this")
+	searchCmd.Flags().StringVarP(&searchRepo, "repo", "r", "", "Only concepts of this repository")
+	searchCmd.Flags().StringVar(&searchType, "type", "", "Only concepts of this type (e.g. 'jsonnet')")
+}
@@ -0,0 +1,79 @@
+/*
+Copyright © 2020 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/redradrat/kable/pkg/repositories"
+	"github.com/spf13/cobra"
+)
+
+// statusRepoCmd represents the repo status command
+var statusRepoCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show the cache status of all configured repositories",
+	Run: func(cmd *cobra.Command, args []string) {
+		initConfig()
+		repos, err := repositories.ListRepositories()
+		if err != nil {
+			PrintError("cannot list repositories: %s \n", err)
+		}
+
+		var lines [][]string
+		for _, repo := range repos {
+			status, err := repo.CacheStatus()
+			if err != nil {
+				lines = append(lines, []string{repo.Name, repo.GitRef, color.RedString("error"), "-", err.Error()})
+				continue
+			}
+			cache := color.GreenString("cached")
+			switch {
+			case repo.IsLocal():
+				cache = "local"
+			case !status.Cached:
+				cache = color.RedString("missing")
+			}
+			commit := "-"
+			if len(status.Commit) >= 7 {
+				commit = status.Commit[:7]
+			}
+			lines = append(lines, []string{repo.Name, repo.GitRef, cache, commit, lastUpdate(status)})
+		}
+		PrintTable([]string{"ID", "Ref", "Cache", "Commit", "Updated"}, lines...)
+	},
+}
+
+// lastUpdate describes how long ago the repository cache has been updated
+func lastUpdate(status *repositories.CacheStatus) string {
+	if status.LastUpdate.IsZero() {
+		return "-"
+	}
+	age := time.Since(status.LastUpdate)
+	switch {
+	case age < time.Minute:
+		return "just now"
+	case age < 48*time.Hour:
+		return age.Round(time.Minute).String() + " ago"
+	default:
+		return status.LastUpdate.Format("2006-01-02")
+	}
+}
+
+func init() {
+	repoCmd.AddCommand(statusRepoCmd)
+}
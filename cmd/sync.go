@@ -0,0 +1,86 @@
+/*
+Copyright © 2020 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"path/filepath"
+
+	"github.com/fatih/color"
+	"github.com/redradrat/kable/pkg/concepts"
+
+	"github.com/spf13/cobra"
+)
+
+var projectFile string
+var syncConcurrency int
+var syncPrune bool
+
+// syncCmd represents the sync command
+var syncCmd = &cobra.Command{
+	Use:   "sync",
+	Short: "Render all instances declared in a project file",
+	Example: `
+kable sync
+kable sync -f clusters/kable.project.json --prune
+`,
+	Run: func(cmd *cobra.Command, args []string) {
+		initConfig()
+		project, err := concepts.ParseProjectFromFile(projectFile)
+		if err != nil {
+			PrintError("unable to read project file: %s", err)
+		}
+
+		PrintMsg("Syncing %d instances...", len(project.Instances))
+		report, err := concepts.SyncProject(*project, filepath.Dir(projectFile), concepts.SyncOpts{
+			Concurrency: syncConcurrency,
+			Prune:       syncPrune,
+		})
+		if report == nil {
+			PrintError("unable to sync project: %s", err)
+		}
+
+		var lines [][]string
+		for _, res := range report.Results {
+			status := color.GreenString("synced")
+			errMsg := ""
+			if res.Err != nil {
+				status = color.RedString("failed")
+				errMsg = res.Err.Error()
+			}
+			lines = append(lines, []string{res.Instance.Concept, res.Instance.Output, status, errMsg})
+		}
+		PrintTable([]string{"Concept", "Output", "Status", "Error"}, lines...)
+
+		for _, dir := range report.Pruned {
+			PrintMsg("Pruned '%s'", dir)
+		}
+		if err != nil {
+			PrintError("unable to prune project: %s", err)
+		}
+		if failed := report.Failed(); len(failed) != 0 {
+			PrintError("%d of %d instances failed to sync", len(failed), len(report.Results))
+		}
+		PrintSuccess("Successfully synced project!")
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(syncCmd)
+
+	syncCmd.Flags().StringVarP(&projectFile, "file", "f", concepts.ProjectFileName, "The project file declaring the instances to render")
+	syncCmd.Flags().IntVarP(&syncConcurrency, "concurrency", "j", concepts.DefaultSyncConcurrency, "The number of instances to render in parallel")
+	syncCmd.Flags().BoolVar(&syncPrune, "prune", false, "Remove rendered output directories that are no longer declared")
+}
@@ -16,10 +16,15 @@ limitations under the License.
 package cmd
 
 import (
+	"os"
+
+	"github.com/fatih/color"
 	"github.com/redradrat/kable/pkg/repositories"
 	"github.com/spf13/cobra"
 )
 
+var updateConcurrency int
+
 // updateCmd represents the update command
 var updateCmd = &cobra.Command{
 	Use:   "update",
@@ -27,23 +32,44 @@ var updateCmd = &cobra.Command{
 	Run: func(cmd *cobra.Command, args []string) {
 		initConfig()
 		PrintMsg("Updating repositories...")
-		if err := repositories.UpdateRepositories(); err != nil {
+		opts := repositories.UpdateOpts{Concurrency: updateConcurrency}
+		if !silent {
+			opts.Progress = os.Stderr
+		}
+		report, err := repositories.UpdateRepositoriesWithOpts(opts)
+		if err != nil {
 			PrintError("unable to update repositories: %s", err)
 		}
-		PrintSuccess("Successfully update repositories!")
+
+		var lines [][]string
+		for _, res := range report.Results {
+			status := string(res.Status)
+			switch res.Status {
+			case repositories.UpdatedStatus:
+				status = color.GreenString(status)
+			case repositories.FailedStatus:
+				status = color.RedString(status)
+			}
+			commit, errMsg := "-", ""
+			if len(res.Commit) >= 7 {
+				commit = res.Commit[:7]
+			}
+			if res.Err != nil {
+				errMsg = res.Err.Error()
+			}
+			lines = append(lines, []string{res.Repository.Name, status, commit, errMsg})
+		}
+		PrintTable([]string{"ID", "Status", "Commit", "Error"}, lines...)
+
+		if failed := report.Failed(); len(failed) != 0 {
+			PrintError("%d of %d repositories failed to update", len(failed), len(report.Results))
+		}
+		PrintSuccess("Successfully updated repositories!")
 	},
 }
 
 func init() {
 	repoCmd.AddCommand(updateCmd)
 
-	// Here you will define your flags and configuration settings.
-
-	// Cobra supports Persistent Flags which will work for this command
-	// and all subcommands, e.g.:
-	// updateCmd.PersistentFlags().String("foo", "", "A help for foo")
-
-	// Cobra supports local flags which will only run when this command
-	// is called directly, e.g.:
-	// updateCmd.Flags().BoolP("toggle", "t", false, "Help message for toggle")
+	updateCmd.Flags().IntVarP(&updateConcurrency, "concurrency", "j", repositories.DefaultUpdateConcurrency, "The number of repositories to update in parallel")
 }
@@ -0,0 +1,121 @@
+/*
+Copyright © 2020 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/redradrat/kable/pkg/concepts"
+	"github.com/redradrat/kable/pkg/repositories"
+	"github.com/spf13/cobra"
+)
+
+const (
+	verifyTextOutput = "text"
+	verifyJSONOutput = "json"
+)
+
+var verifyRepoLocal bool
+var verifyRepoOutput string
+
+// verifyRepoCmd represents the repo verify command
+var verifyRepoCmd = &cobra.Command{
+	Use:   "verify REPO",
+	Short: "Check that all concepts of a repository are valid and render",
+	Long: `Checks every concept listed by the repository: its concept.json has to parse, its type and input types have 
+to be supported, its jsonnet dependencies have to be vendored, and it has to render with the examples of its inputs.
+Exits with 1, if any concept fails. With --local, REPO is a repository directory (default: current directory), which
+makes this usable in the CI of a concept repository.`,
+	Example: `
+kable repo verify myrepo
+kable repo verify --local . -o json
+`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if verifyRepoLocal {
+			if len(args) > 1 {
+				return errors.New("requires at most ONE argument")
+			}
+		} else if len(args) != 1 {
+			return errors.New("requires exactly ONE argument")
+		}
+		return nil
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		initConfig()
+		if verifyRepoOutput != verifyTextOutput && verifyRepoOutput != verifyJSONOutput {
+			PrintError("unsupported output format '%s'", verifyRepoOutput)
+		}
+
+		dir := "."
+		if len(args) == 1 {
+			dir = args[0]
+		}
+		if !verifyRepoLocal {
+			repo, err := repositories.GetRepository(args[0])
+			if err != nil {
+				PrintError("unable to get repository: %s", err)
+			}
+			if dir, err = repo.AbsolutePath(); err != nil {
+				PrintError("unable to get repository path: %s", err)
+			}
+		}
+
+		report, err := concepts.VerifyRepository(dir)
+		if err != nil {
+			PrintError("unable to verify repository: %s", err)
+		}
+
+		if verifyRepoOutput == verifyJSONOutput {
+			out, err := json.MarshalIndent(report, "", "  ")
+			if err != nil {
+				PrintError("unable to marshal report: %s", err)
+			}
+			fmt.Println(string(out))
+		} else {
+			var lines [][]string
+			for _, res := range report.Results {
+				status := color.GreenString("pass")
+				if !res.Passed() {
+					status = color.RedString("fail")
+				}
+				lines = append(lines, []string{res.Concept, status, strings.Join(res.Errors, "; ")})
+			}
+			PrintTable([]string{"Concept", "Status", "Details"}, lines...)
+		}
+
+		if failed := report.Failed(); len(failed) != 0 {
+			if verifyRepoOutput == verifyTextOutput {
+				PrintError("%d of %d concepts failed verification", len(failed), len(report.Results))
+			}
+			os.Exit(1)
+		}
+		if verifyRepoOutput == verifyTextOutput {
+			PrintSuccess("All %d concepts passed verification!", len(report.Results))
+		}
+	},
+}
+
+func init() {
+	repoCmd.AddCommand(verifyRepoCmd)
+
+	verifyRepoCmd.Flags().BoolVarP(&verifyRepoLocal, "local", "l", false, "Verify the repository directory REPO instead of a configured repository")
+	verifyRepoCmd.Flags().StringVarP(&verifyRepoOutput, "output", "o", verifyTextOutput, "The report format ("+strings.Join([]string{verifyTextOutput, verifyJSONOutput}, ", ")+")")
+}
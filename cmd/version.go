@@ -16,6 +16,7 @@ limitations under the License.
 package cmd
 
 import (
+	"github.com/redradrat/kable/pkg/concepts"
 	"github.com/spf13/cobra"
 )
 
@@ -33,6 +34,7 @@ var versionCmd = &cobra.Command{
 
 func init() {
 	rootCmd.AddCommand(versionCmd)
+	concepts.KableVersion = CliVersion
 
 	// Here you will define your flags and configuration settings.
 
@@ -4,10 +4,13 @@ go 1.14
 
 require (
 	github.com/AlecAivazis/survey/v2 v2.1.1
+	github.com/Masterminds/semver/v3 v3.1.1
 	github.com/coreos/pkg v0.0.0-20180928190104-399ea9e2e55f
 	github.com/fatih/color v1.13.0
 	github.com/fatih/structs v1.1.0
+	github.com/fsnotify/fsnotify v1.4.9
 	github.com/go-git/go-git/v5 v5.1.0
+	github.com/gobwas/glob v0.2.3
 	github.com/gofiber/fiber/v2 v2.3.0
 	github.com/gofiber/template v1.6.6
 	github.com/google/go-querystring v1.0.0
@@ -25,6 +28,9 @@ require (
 	github.com/spf13/viper v1.7.0
 	github.com/stretchr/testify v1.7.0
 	go.etcd.io/etcd/client/v3 v3.5.1
+	golang.org/x/crypto v0.0.0-20210921155107-089bfa567519
+	golang.org/x/sys v0.0.0-20210630005230-0f9fa26af87c
+	sigs.k8s.io/yaml v1.3.0
 )
 
 replace github.com/Joker/jade v1.0.0 => github.com/Joker/jade v1.0.1-0.20200506134858-ee26e3c533bb
@@ -22,6 +22,18 @@ type ConceptsService interface {
 	List(context.Context, *ListOptions) (*api.ConceptsPayload, *Response, error)
 	ListFromRepository(context.Context, string, *ListOptions) (*api.ConceptsPayload, *Response, error)
 	ListByTag(context.Context, string, *ListOptions) (*api.ConceptsPayload, *Response, error)
+	Search(context.Context, *SearchOptions) (*api.ConceptsPayload, *Response, error)
+}
+
+// SearchOptions defines the server-side filters of a concept search. Concepts are returned ranked by relevance.
+type SearchOptions struct {
+	ListOptions
+	// Query are free text terms, matched against name, path, tags, description and maintainer
+	Query      string   `url:"q,omitempty"`
+	Tags       []string `url:"tag,omitempty"`
+	Maintainer string   `url:"maintainer,omitempty"`
+	Repository string   `url:"repository,omitempty"`
+	Type       string   `url:"type,omitempty"`
 }
 
 var _ ConceptsService = &ConceptsClient{}
@@ -46,30 +58,15 @@ func (c ConceptsClient) List(ctx context.Context, options *ListOptions) (*api.Co
 }
 
 func (c ConceptsClient) ListByTag(ctx context.Context, s string, options *ListOptions) (*api.ConceptsPayload, *Response, error) {
-	payload, resp, err := c.List(ctx, options)
-	if err != nil {
-		return nil, resp, err
+	search := &SearchOptions{Tags: []string{s}}
+	if options != nil {
+		search.ListOptions = *options
 	}
-
-	filteredPayload := *payload
-
-	for path, concept := range filteredPayload.Concepts {
-		if !hasEntry(concept.Metadata.Tags, s) {
-			delete(filteredPayload.Concepts, path)
-		}
-	}
-
-	return &filteredPayload, resp, err
+	return c.Search(ctx, search)
 }
 
-func hasEntry(l []string, s string) bool {
-	hasEntry := false
-	for _, tag := range l {
-		if tag == s {
-			hasEntry = true
-		}
-	}
-	return hasEntry
+func (c ConceptsClient) Search(ctx context.Context, options *SearchOptions) (*api.ConceptsPayload, *Response, error) {
+	return c.listWithBasepath(conceptsBasePath, ctx, options)
 }
 
 func (c ConceptsClient) ListFromRepository(ctx context.Context, repo string, options *ListOptions) (*api.ConceptsPayload, *Response, error) {
@@ -102,7 +99,7 @@ func (c ConceptsClient) getWithBasepath(basePath string, ctx context.Context, id
 	return &payload, resp, err
 }
 
-func (c ConceptsClient) listWithBasepath(basePath string, ctx context.Context, options *ListOptions) (*api.ConceptsPayload, *Response, error) {
+func (c ConceptsClient) listWithBasepath(basePath string, ctx context.Context, options interface{}) (*api.ConceptsPayload, *Response, error) {
 	uri, err := url.Parse(basePath)
 	if err != nil {
 		return nil, nil, err
@@ -33,6 +33,8 @@ type RepositoryPayload struct {
 
 type ConceptsPayload struct {
 	Concepts ConceptsMapPayload `json:"concepts"`
+	// Ranking lists the concept identifiers by relevance, if the concepts have been searched
+	Ranking []string `json:"ranking,omitempty"`
 }
 
 type ConceptsMapPayload map[string]ConceptPayload
@@ -54,8 +56,10 @@ func NewConceptPayload() ConceptPayload {
 }
 
 type ConceptMetadataPayload struct {
-	Maintainer ConceptMaintainerPayload `json:"maintainer,omitempty"`
-	Tags       []string                 `json:"tags,omitempty"`
+	Name        string                   `json:"name,omitempty"`
+	Description string                   `json:"description,omitempty"`
+	Maintainer  ConceptMaintainerPayload `json:"maintainer,omitempty"`
+	Tags        []string                 `json:"tags,omitempty"`
 }
 
 type ConceptMaintainerPayload struct {
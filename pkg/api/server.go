@@ -86,6 +86,10 @@ func (serv Serv) PutRepository(ctx echo.Context) error {
 		},
 		Name: name,
 	}
+	// Local directories and paths would expose the file system of the server to any client, so they are CLI-only
+	if repo.IsLocal() || !repositories.IsRemoteURL(repo.URL) {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("repository url '%s' is not a remote git url", repo.URL))
+	}
 	addMod, err := repositories.AddRepository(repo)
 	if err != nil {
 		ctx.Logger().Errorf("unable to add repository: %v", err)
@@ -130,20 +134,35 @@ func (serv Serv) GetRepositories(ctx echo.Context) error {
 
 func (serv Serv) GetConcepts(ctx echo.Context) error {
 	ctx.Logger().Infof("'%s' hit by user-agent => %s [%s]", ctx.Path(), ctx.Request().UserAgent(), ctx.RealIP())
-	cpts, err := concepts.ListConcepts()
+	q := searchQueryFromContext(ctx)
+	return searchConcepts(ctx, q, !q.IsEmpty())
+}
+
+// searchQueryFromContext reads the search query parameters 'q', 'tag' (repeatable), 'maintainer', 'repository' and
+// 'type'.
+func searchQueryFromContext(ctx echo.Context) concepts.SearchQuery {
+	return concepts.SearchQuery{
+		Text:       ctx.QueryParam("q"),
+		Tags:       ctx.QueryParams()["tag"],
+		Maintainer: ctx.QueryParam("maintainer"),
+		Repository: ctx.QueryParam("repository"),
+		Type:       concepts.ConceptType(ctx.QueryParam("type")),
+	}
+}
+
+// searchConcepts responds with the concepts matching the query, and their ranking if requested.
+func searchConcepts(ctx echo.Context, q concepts.SearchQuery, ranked bool) error {
+	results, err := concepts.SearchConcepts(q)
 	if err != nil {
 		ctx.Logger().Errorf("unable to list concepts: %v", err)
 		return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("unable to list concepts: %v", err))
 	}
 	payload := NewConceptsPayload()
-	for _, cpt := range cpts {
-		concept, err := concepts.GetRepoConcept(cpt)
-		if err != nil {
-			ctx.Logger().Errorf("unable to get concept '%s': %v", cpt.String(), err)
-			return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("unable to get concept '%s': %v", cpt.String(), err))
+	for _, res := range results {
+		payload.Concepts[res.ID.String()] = constructConceptPayloadFrom(res.Concept)
+		if ranked {
+			payload.Ranking = append(payload.Ranking, res.ID.String())
 		}
-
-		payload.Concepts[cpt.String()] = constructConceptPayloadFrom(concept)
 	}
 	return ctx.JSON(http.StatusOK, payload)
 }
@@ -192,25 +211,10 @@ func (serv Serv) GetRepositoryConcepts(ctx echo.Context) error {
 		ctx.Logger().Errorf("could not get repo: %v", err)
 		return err
 	}
-	cpts, err := concepts.ListConcepts()
-	if err != nil {
-		ctx.Logger().Errorf("unable to list concepts: %v", err)
-		return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("unable to list concepts: %v", err))
-	}
-	payload := NewConceptsPayload()
-	for _, cpt := range cpts {
-		if cpt.Repo() != id {
-			continue
-		}
-		concept, err := concepts.GetRepoConcept(cpt)
-		if err != nil {
-			ctx.Logger().Errorf("unable to get concept '%s': %v", cpt.Concept(), err)
-			return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("unable to get concept '%s': %v", cpt.Concept(), err))
-		}
-
-		payload.Concepts[cpt.String()] = constructConceptPayloadFrom(concept)
-	}
-	return ctx.JSON(http.StatusOK, payload)
+	q := searchQueryFromContext(ctx)
+	ranked := !q.IsEmpty()
+	q.Repository = id
+	return searchConcepts(ctx, q, ranked)
 }
 
 func (serv Serv) GetRepositoryConcept(ctx echo.Context) error {
@@ -265,10 +269,13 @@ func (serv Serv) RenderRepositoryConcept(ctx echo.Context) error {
 }
 
 func renderConcept(ci concepts.ConceptIdentifier, inPayload *RenderConceptInputPayload) (RenderConceptResultPayload, error) {
+	// Value references and hooks would let any client read the server's environment and files, or run commands on it
 	rdr, err := concepts.RenderConcept(ci.String(), inPayload.Values, concepts.TargetType(inPayload.TargetType), concepts.RenderOpts{
-		Local:           false,
-		WriteRenderInfo: true,
-		Single:          inPayload.SingleManifest,
+		Local:             false,
+		WriteRenderInfo:   true,
+		Single:            inPayload.SingleManifest,
+		NoValueReferences: true,
+		NoHooks:           true,
 	})
 	if err != nil {
 		return RenderConceptResultPayload{}, echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("could not render values with given input: %v", err))
@@ -331,7 +338,9 @@ func constructConceptPayloadFrom(cpt *concepts.Concept) ConceptPayload {
 	return ConceptPayload{
 		Type: cpt.Type.String(),
 		Metadata: ConceptMetadataPayload{
-			Tags: cpt.Meta.Tags,
+			Name:        cpt.Meta.Name,
+			Description: cpt.Meta.Description,
+			Tags:        cpt.Meta.Tags,
 			Maintainer: ConceptMaintainerPayload{
 				MaintainerName:  cpt.Meta.Maintainer.Name,
 				MaintainerEmail: cpt.Meta.Maintainer.Email,
@@ -0,0 +1,185 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	goerrors "errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/spf13/viper"
+
+	"github.com/redradrat/kable/pkg/concepts"
+	"github.com/redradrat/kable/pkg/repositories"
+)
+
+// setupTestRepository registers the local repository 'api-test' in '<dir>/src', with concept 'c' rendering a ConfigMap
+// named after the value 'name'. It returns the temporary directory, and the function cleaning up.
+func setupTestRepository(t *testing.T) (string, func()) {
+	viper.Set(repositories.StoreKey, repositories.MockStoreConfigMap().Map())
+	dir, err := ioutil.TempDir("", "kable-api")
+	if err != nil {
+		t.Fatal(err)
+	}
+	origCacheDir := concepts.RenderCacheDir
+	concepts.RenderCacheDir = filepath.Join(dir, "rendercache")
+	cleanup := func() {
+		if mod, err := repositories.RemoveRepository("api-test"); err == nil {
+			_ = repositories.UpdateRegistry(mod)
+		}
+		concepts.RenderCacheDir = origCacheDir
+		viper.Set(repositories.StoreKey, nil)
+		os.RemoveAll(dir)
+	}
+
+	src := filepath.Join(dir, "src")
+	files := map[string]string{
+		repositories.RepoIndexFileName:     `{"version": 1, "concepts": ["c"]}`,
+		"c/" + concepts.ConceptFileName:    `{"apiVersion": 1, "type": "jsonnet", "metadata": {"name": "c"}, "inputs": {"mandatory": {"name": {"type": "string"}}}}`,
+		"c/" + concepts.ConceptJsonnetfile: `{"version": 1, "dependencies": []}`,
+		"c/" + concepts.ConceptMainJsonnet: `{ cm: { apiVersion: "v1", kind: "ConfigMap", metadata: { name: std.extVar("name") } } }`,
+	}
+	for name, content := range files {
+		path := filepath.Join(src, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+			cleanup()
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			cleanup()
+			t.Fatal(err)
+		}
+	}
+	mod, err := repositories.AddRepository(repositories.Repository{Name: "api-test", GitRepository: repositories.GitRepository{URL: repositories.LocalURLScheme + src}})
+	if err != nil {
+		cleanup()
+		t.Fatal(err)
+	}
+	if err := repositories.UpdateRegistry(mod); err != nil {
+		cleanup()
+		t.Fatal(err)
+	}
+	return dir, cleanup
+}
+
+func Test_renderConcept_valueReferences(t *testing.T) {
+	dir, cleanup := setupTestRepository(t)
+	defer cleanup()
+
+	marker := filepath.Join(dir, "executed")
+	tests := []struct {
+		name    string
+		value   concepts.ValueType
+		wantErr bool
+	}{
+		{name: "plain", value: concepts.StringValueType("demo")},
+		{name: "exec", value: concepts.StringValueType("ref+exec://touch " + marker), wantErr: true},
+		{name: "file", value: concepts.StringValueType("ref+file:///etc/hostname"), wantErr: true},
+		{name: "env", value: concepts.StringValueType("ref+env://HOME"), wantErr: true},
+		{name: "nested", value: concepts.MapValueType{"a": map[string]interface{}{"b": "ref+exec://touch " + marker}}, wantErr: true},
+		{name: "array", value: concepts.MapValueType{"a": []interface{}{"ref+exec://touch " + marker}}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			payload := &RenderConceptInputPayload{
+				TargetType: string(concepts.YamlTargetType),
+				Values:     &concepts.RenderValues{"name": tt.value},
+			}
+			_, err := renderConcept(concepts.NewConceptIdentifier("c", "api-test"), payload)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("renderConcept() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			var httpErr *echo.HTTPError
+			if tt.wantErr && (!goerrors.As(err, &httpErr) || httpErr.Code != http.StatusBadRequest) {
+				t.Errorf("renderConcept() error = %v, want status %d", err, http.StatusBadRequest)
+			}
+			if _, err := os.Stat(marker); !os.IsNotExist(err) {
+				t.Fatalf("renderConcept() executed a command of a value reference")
+			}
+		})
+	}
+}
+
+func Test_renderConcept_hooks(t *testing.T) {
+	dir, cleanup := setupTestRepository(t)
+	defer cleanup()
+	marker := filepath.Join(dir, "executed")
+	hooked := `{"apiVersion": 1, "type": "jsonnet", "metadata": {"name": "c"}, "inputs": {"mandatory": {"name": {"type": "string"}}}, "hooks": {"preRender": {"command": "touch ` + marker + `"}}}`
+	if err := ioutil.WriteFile(filepath.Join(dir, "src", "c", concepts.ConceptFileName), []byte(hooked), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	payload := &RenderConceptInputPayload{
+		TargetType: string(concepts.YamlTargetType),
+		Values:     &concepts.RenderValues{"name": concepts.StringValueType("demo")},
+	}
+	_, err := renderConcept(concepts.NewConceptIdentifier("c", "api-test"), payload)
+	var httpErr *echo.HTTPError
+	if !goerrors.As(err, &httpErr) || httpErr.Code != http.StatusBadRequest {
+		t.Errorf("renderConcept() error = %v, want status %d", err, http.StatusBadRequest)
+	}
+	if _, err := os.Stat(marker); !os.IsNotExist(err) {
+		t.Errorf("renderConcept() ran a hook of the concept")
+	}
+}
+
+func TestServ_PutRepository(t *testing.T) {
+	viper.Set(repositories.StoreKey, repositories.MockStoreConfigMap().Map())
+	defer viper.Set(repositories.StoreKey, nil)
+	dir, err := ioutil.TempDir("", "kable-api")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	tests := []struct {
+		name     string
+		url      string
+		wantCode int
+	}{
+		{name: "https", url: "https://github.com/redradrat/demo-concepts.git", wantCode: http.StatusOK},
+		{name: "ssh", url: "git@github.com:redradrat/demo-concepts.git", wantCode: http.StatusOK},
+		{name: "local", url: repositories.LocalURLScheme + dir, wantCode: http.StatusBadRequest},
+		{name: "absolute path", url: dir, wantCode: http.StatusBadRequest},
+		{name: "relative path", url: "./concepts", wantCode: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func() {
+				if mod, err := repositories.RemoveRepository("put-test"); err == nil {
+					_ = repositories.UpdateRegistry(mod)
+				}
+			}()
+			body, err := json.Marshal(RepositoryPayload{URL: tt.url})
+			if err != nil {
+				t.Fatal(err)
+			}
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodPut, RepositoriesApiPath+"/put-test", bytes.NewReader(body))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			rec := httptest.NewRecorder()
+			ctx := e.NewContext(req, rec)
+			ctx.SetParamNames("id")
+			ctx.SetParamValues("put-test")
+
+			code := http.StatusOK
+			var httpErr *echo.HTTPError
+			if err := (Serv{}).PutRepository(ctx); goerrors.As(err, &httpErr) {
+				code = httpErr.Code
+			} else if err != nil {
+				t.Fatal(err)
+			}
+			if code != tt.wantCode {
+				t.Errorf("PutRepository() status = %d, want %d", code, tt.wantCode)
+			}
+			if _, err := repositories.GetRepository("put-test"); (err == nil) != (tt.wantCode == http.StatusOK) {
+				t.Errorf("PutRepository() registered = %t, want %t", err == nil, tt.wantCode == http.StatusOK)
+			}
+		})
+	}
+}
@@ -0,0 +1,225 @@
+package concepts
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/grafana/tanka/pkg/jsonnet/jpath"
+
+	"github.com/redradrat/kable/pkg/repositories"
+)
+
+const (
+	RenderCacheDirName        = "rendercache"
+	DefaultRenderCacheMaxSize = 256 << 20
+	renderCacheFileSuffix     = ".json"
+)
+
+var (
+	// KableVersion is part of every render cache key, so a new kable version never serves stale renders
+	KableVersion             = "devel"
+	RenderCacheDir           = filepath.Join(repositories.KableDir, RenderCacheDirName)
+	RenderCacheMaxSize int64 = DefaultRenderCacheMaxSize
+)
+
+type cachedFile struct {
+	Path    string `json:"path"`
+	Content []byte `json:"content"`
+}
+
+// renderCacheKey computes a content-addressed key from the concept directory tree, the shared directories it imports
+// from, the (resolved) values, the target type and the kable version.
+func renderCacheKey(path string, ct ConceptType, avs *RenderValues, ttype TargetType, single bool) (string, error) {
+	h := sha256.New()
+	fmt.Fprintf(h, "kable:%s\ntarget:%s\nsingle:%t\n", KableVersion, ttype, single)
+
+	if avs != nil {
+		typed := map[string]interface{}{}
+		for k, v := range *avs {
+			typed[k] = []interface{}{v.ValueTypeIdentifier(), v}
+		}
+		b, err := json.Marshal(typed)
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(h, "values:%s\n", b)
+	}
+
+	if err := hashConceptTree(h, path); err != nil {
+		return "", err
+	}
+	if ct == ConceptJsonnetType {
+		if err := hashJsonnetPath(h, path); err != nil {
+			return "", err
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// hashJsonnetPath writes the vendor and lib directories of the project root of the jsonnet concept at path into h, if
+// they are located outside of the concept directory. This way, a repository-wide vendor or lib directory is part of
+// the key of every concept importing from it.
+func hashJsonnetPath(h io.Writer, path string) error {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+	root, err := jpath.FindRoot(abs)
+	if err != nil {
+		return err
+	}
+	for _, name := range []string{ConceptVendorDir, "lib"} {
+		dir := filepath.Join(root, name)
+		if rel, err := filepath.Rel(abs, dir); err == nil && !strings.HasPrefix(rel, "..") {
+			continue
+		}
+		if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+			continue
+		}
+		fmt.Fprintf(h, "jpath:%s\n", name)
+		if err := hashConceptTree(h, dir); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// hashConceptTree writes all files of the concept directory (including vendor) into h. Dot-directories and rendered
+// output directories (containing a renderinfo.json) are skipped, as they don't contribute to the evaluation.
+func hashConceptTree(h io.Writer, root string) error {
+	return filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			if path == root {
+				return nil
+			}
+			if strings.HasPrefix(info.Name(), ".") {
+				return filepath.SkipDir
+			}
+			if _, err := os.Stat(filepath.Join(path, ConceptRenderFileName)); err == nil {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		fmt.Fprintf(h, "file:%s:%d\n", filepath.ToSlash(rel), info.Size())
+		_, err = io.Copy(h, f)
+		return err
+	})
+}
+
+func renderCachePath(key string) string {
+	return filepath.Join(RenderCacheDir, key+renderCacheFileSuffix)
+}
+
+// loadCachedRender returns the cached files for the given key, or nil if there is no such cache entry
+func loadCachedRender(key string) *Render {
+	path := renderCachePath(key)
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	var files []cachedFile
+	if err := json.Unmarshal(b, &files); err != nil {
+		return nil
+	}
+
+	// Mark the entry as recently used for eviction
+	now := time.Now()
+	_ = os.Chtimes(path, now, now)
+
+	render := Render{}
+	for _, f := range files {
+		render.Files = append(render.Files, File{path: f.Path, content: f.Content})
+	}
+	return &render
+}
+
+// storeCachedRender writes the files of the given render to the cache, and evicts the least recently used entries, if
+// the cache exceeds RenderCacheMaxSize.
+func storeCachedRender(key string, render *Render) error {
+	if err := os.MkdirAll(RenderCacheDir, os.ModePerm); err != nil {
+		return err
+	}
+	var files []cachedFile
+	for _, f := range render.Files {
+		files = append(files, cachedFile{Path: f.path, Content: f.content})
+	}
+	b, err := json.Marshal(files)
+	if err != nil {
+		return err
+	}
+
+	// Write to a temporary file first, so concurrent renders never read partial entries
+	tmp, err := ioutil.TempFile(RenderCacheDir, key+".tmp-")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(b); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Rename(tmp.Name(), renderCachePath(key)); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	return evictRenderCache(RenderCacheMaxSize)
+}
+
+func evictRenderCache(maxSize int64) error {
+	infos, err := ioutil.ReadDir(RenderCacheDir)
+	if err != nil {
+		return err
+	}
+	var entries []os.FileInfo
+	var size int64
+	for _, info := range infos {
+		if info.IsDir() || !strings.HasSuffix(info.Name(), renderCacheFileSuffix) {
+			continue
+		}
+		entries = append(entries, info)
+		size += info.Size()
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].ModTime().Before(entries[j].ModTime())
+	})
+	for _, entry := range entries {
+		if size <= maxSize {
+			break
+		}
+		if err := os.Remove(filepath.Join(RenderCacheDir, entry.Name())); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		size -= entry.Size()
+	}
+	return nil
+}
@@ -0,0 +1,175 @@
+package concepts
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func withTempRenderCache(t *testing.T) func() {
+	dir, err := ioutil.TempDir("", "kable-rendercache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	orig := RenderCacheDir
+	RenderCacheDir = dir
+	return func() {
+		RenderCacheDir = orig
+		os.RemoveAll(dir)
+	}
+}
+
+func TestRenderCacheKey(t *testing.T) {
+	path := "../../e2e-test/testconcept1"
+	vals := &RenderValues{"instanceName": StringValueType("test")}
+	base, err := renderCacheKey(path, ConceptJsonnetType, vals, YamlTargetType, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		vals   *RenderValues
+		ttype  TargetType
+		single bool
+		same   bool
+	}{
+		{name: "identical", vals: &RenderValues{"instanceName": StringValueType("test")}, ttype: YamlTargetType, same: true},
+		{name: "other value", vals: &RenderValues{"instanceName": StringValueType("other")}, ttype: YamlTargetType},
+		{name: "other value type", vals: &RenderValues{"instanceName": IntValueType(1)}, ttype: YamlTargetType},
+		{name: "other target", vals: vals, ttype: CRDTargetType},
+		{name: "single", vals: vals, ttype: YamlTargetType, single: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := renderCacheKey(path, ConceptJsonnetType, tt.vals, tt.ttype, tt.single)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if (got == base) != tt.same {
+				t.Errorf("renderCacheKey() = %v, base %v, want same %v", got, base, tt.same)
+			}
+		})
+	}
+}
+
+func TestRenderCacheKey_projectRoot(t *testing.T) {
+	root, err := ioutil.TempDir("", "kable-rendercache-root")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+	files := map[string]string{
+		ConceptJsonnetfile:                `{"version": 1, "dependencies": []}`,
+		"lib/shared.libsonnet":            `{ name: "shared" }`,
+		"vendor/github.com/x/x.libsonnet": `{}`,
+		"c/" + ConceptFileName:            `{"apiVersion": 1, "type": "jsonnet", "metadata": {"name": "c"}}`,
+		"c/" + ConceptMainJsonnet:         `(import "shared.libsonnet")`,
+	}
+	for name, content := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	path := filepath.Join(root, "c")
+	base, err := renderCacheKey(path, ConceptJsonnetType, nil, YamlTargetType, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"lib/shared.libsonnet", "vendor/github.com/x/x.libsonnet"} {
+		if err := ioutil.WriteFile(filepath.Join(root, filepath.FromSlash(name)), []byte(`{ name: "changed" }`), 0644); err != nil {
+			t.Fatal(err)
+		}
+		got, err := renderCacheKey(path, ConceptJsonnetType, nil, YamlTargetType, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got == base {
+			t.Errorf("renderCacheKey() expected change of '%s' to change the key", name)
+		}
+		base = got
+	}
+}
+
+func TestRenderConcept_Cached(t *testing.T) {
+	defer withTempRenderCache(t)()
+	vals := &RenderValues{"instanceName": StringValueType("test"), "nameSelection": StringValueType("Option 1")}
+	opts := RenderOpts{Local: true}
+	path := "../../e2e-test/testconcept1"
+
+	first, err := RenderConcept(path, vals, YamlTargetType, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := renderCacheKey(path, ConceptJsonnetType, vals, YamlTargetType, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(renderCachePath(key)); err != nil {
+		t.Fatalf("RenderConcept() expected cache entry: %v", err)
+	}
+
+	second, err := RenderConcept(path, vals, YamlTargetType, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.PrintFiles() != second.PrintFiles() || len(first.Files) != len(second.Files) {
+		t.Errorf("RenderConcept() cached render differs from original")
+	}
+	if second.Info == nil {
+		t.Errorf("RenderConcept() cached render has no renderinfo")
+	}
+
+	// Resolved references may be secrets, so renders with references are never cached
+	os.Setenv("KABLE_TEST_SECRET", "secret")
+	defer os.Unsetenv("KABLE_TEST_SECRET")
+	if err := os.RemoveAll(RenderCacheDir); err != nil {
+		t.Fatal(err)
+	}
+	refVals := &RenderValues{"instanceName": StringValueType("ref+env://KABLE_TEST_SECRET"), "nameSelection": StringValueType("Option 1")}
+	if _, err := RenderConcept(path, refVals, YamlTargetType, opts); err != nil {
+		t.Fatal(err)
+	}
+	if entries, _ := ioutil.ReadDir(RenderCacheDir); len(entries) != 0 {
+		t.Errorf("RenderConcept() cached a render with value references")
+	}
+}
+
+func TestEvictRenderCache(t *testing.T) {
+	defer withTempRenderCache(t)()
+	render := &Render{Files: []File{{path: "a.yaml", content: make([]byte, 1024)}}}
+	for i, key := range []string{"old", "middle", "new"} {
+		if err := storeCachedRender(key, render); err != nil {
+			t.Fatal(err)
+		}
+		mtime := time.Now().Add(time.Duration(i-3) * time.Hour)
+		if err := os.Chtimes(renderCachePath(key), mtime, mtime); err != nil {
+			t.Fatal(err)
+		}
+	}
+	info, err := os.Stat(renderCachePath("new"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := evictRenderCache(2 * info.Size()); err != nil {
+		t.Fatal(err)
+	}
+	entries, err := filepath.Glob(filepath.Join(RenderCacheDir, "*"+renderCacheFileSuffix))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 {
+		t.Errorf("evictRenderCache() left %d entries, want 2", len(entries))
+	}
+	if loadCachedRender("old") != nil {
+		t.Errorf("evictRenderCache() expected least recently used entry to be evicted")
+	}
+}
@@ -136,10 +136,12 @@ func NewConceptIdentifier(path, repoid string) ConceptIdentifier {
 
 // Concept defines model for Concept.
 type Concept struct {
-	ApiVersion int           `json:"apiVersion"`
-	Type       ConceptType   `json:"type"`
-	Meta       ConceptMeta   `json:"metadata"`
-	Inputs     ConceptInputs `json:"inputs,omitempty"`
+	ApiVersion int               `json:"apiVersion"`
+	Type       ConceptType       `json:"type"`
+	Meta       ConceptMeta       `json:"metadata"`
+	Inputs     ConceptInputs     `json:"inputs,omitempty"`
+	Migrations ConceptMigrations `json:"migrations,omitempty"`
+	Hooks      ConceptHooks      `json:"hooks,omitempty"`
 }
 
 func (c *Concept) UnmarshalJSON(bytes []byte) error {
@@ -154,6 +156,8 @@ func (c *Concept) UnmarshalJSON(bytes []byte) error {
 	c.Meta = inter.Meta
 	c.Inputs = inter.Inputs
 	c.Type = inter.Type
+	c.Migrations = inter.Migrations
+	c.Hooks = inter.Hooks
 
 	return nil
 }
@@ -173,9 +177,10 @@ func (ct ConceptType) IsSupported() bool {
 
 // ConceptMeta defines model for ConceptMeta.
 type ConceptMeta struct {
-	Name       string         `json:"name"`
-	Tags       Tags           `json:"tags,omitempty"`
-	Maintainer MaintainerInfo `json:"maintainer,omitempty"`
+	Name        string         `json:"name"`
+	Description string         `json:"description,omitempty"`
+	Tags        Tags           `json:"tags,omitempty"`
+	Maintainer  MaintainerInfo `json:"maintainer,omitempty"`
 }
 
 type Tags []string
@@ -204,7 +209,8 @@ type InputType struct {
 type InputTypeIdentifier string
 
 func (iti InputTypeIdentifier) IsValid() bool {
-	if iti == ConceptSelectionInputType || iti == ConceptStringInputType {
+	switch iti {
+	case ConceptStringInputType, ConceptSelectionInputType, ConceptMapInputType, ConceptIntInputType, ConceptBoolInputType:
 		return true
 	}
 	return false
@@ -242,6 +248,7 @@ func GetConcept(path string) (*Concept, error) {
 type ConceptOrigin struct {
 	Repository string `json:"repository"`
 	Ref        string `json:"ref"`
+	Commit     string `json:"commit,omitempty"`
 }
 
 func GetConceptOriginFromRepository(repositoryName string) (*ConceptOrigin, error) {
@@ -250,13 +257,21 @@ func GetConceptOriginFromRepository(repositoryName string) (*ConceptOrigin, erro
 		return nil, err
 	}
 
-	// Get the origin of the the concept
-	origin := &ConceptOrigin{
-		Repository: r.URL,
-		Ref:        r.GitRef,
+	return conceptOrigin(r)
+}
+
+// conceptOrigin returns the origin of concepts within the given repository, pinned to its current commit
+func conceptOrigin(r repositories.Repository) (*ConceptOrigin, error) {
+	commit, err := r.HeadCommit()
+	if err != nil {
+		return nil, err
 	}
 
-	return origin, nil
+	return &ConceptOrigin{
+		Repository: r.URL,
+		Ref:        r.GitRef,
+		Commit:     commit,
+	}, nil
 }
 
 type ConceptRepoInfo struct {
@@ -288,12 +303,20 @@ func ListConcepts() ([]ConceptIdentifier, error) {
 		return nil, err
 	}
 	for _, repo := range repos {
-		idx, err := repo.RepoIndex()
+		path, err := repo.AbsolutePath()
+		if err != nil {
+			return nil, err
+		}
+		idx, err := repositories.ReadRepoIndex(path)
+		if err != nil {
+			return nil, err
+		}
+		paths, err := RepoConcepts(path, *idx)
 		if err != nil {
 			return nil, err
 		}
-		for _, path := range idx.ConceptEntries {
-			cis = append(cis, NewConceptIdentifier(path, repo.Name))
+		for _, p := range paths {
+			cis = append(cis, NewConceptIdentifier(p, repo.Name))
 		}
 	}
 	return cis, nil
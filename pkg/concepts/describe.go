@@ -0,0 +1,118 @@
+package concepts
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/redradrat/kable/pkg/repositories"
+)
+
+// readmeFileNames are the file names (in any case), that are picked up as a concept's README, in order of preference
+var readmeFileNames = []string{"README.md", "README", "README.txt"}
+
+// ConceptDescription summarizes a concept, its origin and its inputs, to be inspected before rendering.
+type ConceptDescription struct {
+	ID       ConceptIdentifier  `json:"id,omitempty"`
+	Type     ConceptType        `json:"type"`
+	Metadata ConceptMeta        `json:"metadata"`
+	Origin   *ConceptOrigin     `json:"origin,omitempty"`
+	Inputs   []InputDescription `json:"inputs,omitempty"`
+	Readme   string             `json:"readme,omitempty"`
+}
+
+// InputDescription is a single input of a concept, with whether it has to be given.
+type InputDescription struct {
+	ID        string              `json:"id"`
+	Type      InputTypeIdentifier `json:"type"`
+	Mandatory bool                `json:"mandatory"`
+	// Description, Example and Options are taken over from the concept's input definition
+	Description string   `json:"description,omitempty"`
+	Example     string   `json:"example,omitempty"`
+	Options     []string `json:"options,omitempty"`
+}
+
+// DescribeRepoConcept describes the concept with the given identifier, including the commit it is currently checked
+// out at.
+func DescribeRepoConcept(cid ConceptIdentifier) (*ConceptDescription, error) {
+	r, err := repositories.GetRepository(cid.Repo())
+	if err != nil {
+		return nil, err
+	}
+	path, err := r.AbsolutePath()
+	if err != nil {
+		return nil, err
+	}
+	desc, err := DescribeConcept(filepath.Join(path, cid.Concept()))
+	if err != nil {
+		return nil, err
+	}
+	origin, err := conceptOrigin(r)
+	if err != nil {
+		return nil, err
+	}
+	desc.ID = cid
+	desc.Origin = origin
+	return desc, nil
+}
+
+// DescribeConcept describes the concept at the given path. Mandatory inputs are listed before optional ones, each
+// sorted by their ID.
+func DescribeConcept(path string) (*ConceptDescription, error) {
+	c, err := GetConcept(path)
+	if err != nil {
+		return nil, err
+	}
+
+	desc := ConceptDescription{
+		Type:     c.Type,
+		Metadata: c.Meta,
+		Inputs:   append(describeInputs(c.Inputs.Mandatory, true), describeInputs(c.Inputs.Optional, false)...),
+	}
+	readme, err := readConceptReadme(path)
+	if err != nil {
+		return nil, err
+	}
+	desc.Readme = readme
+	return &desc, nil
+}
+
+func describeInputs(inputs map[string]InputType, mandatory bool) []InputDescription {
+	var out []InputDescription
+	for id, in := range inputs {
+		out = append(out, InputDescription{
+			ID:          id,
+			Type:        in.Type,
+			Mandatory:   mandatory,
+			Description: in.Description,
+			Example:     in.Example,
+			Options:     in.Options,
+		})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].ID < out[j].ID
+	})
+	return out
+}
+
+// readConceptReadme returns the content of the concept's README, or an empty string if it has none
+func readConceptReadme(path string) (string, error) {
+	infos, err := ioutil.ReadDir(path)
+	if err != nil {
+		return "", err
+	}
+	for _, name := range readmeFileNames {
+		for _, info := range infos {
+			if info.IsDir() || !strings.EqualFold(info.Name(), name) {
+				continue
+			}
+			content, err := ioutil.ReadFile(filepath.Join(path, info.Name()))
+			if err != nil {
+				return "", err
+			}
+			return strings.TrimSpace(string(content)), nil
+		}
+	}
+	return "", nil
+}
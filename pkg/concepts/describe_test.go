@@ -0,0 +1,63 @@
+package concepts
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDescribeConcept(t *testing.T) {
+	dir, err := ioutil.TempDir("", "kable-describe")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	concept := []byte(`{
+	"apiVersion": 1,
+	"type": "jsonnet",
+	"metadata": {"name": "described", "description": "A described concept"},
+	"inputs": {
+		"mandatory": {
+			"name": {"type": "string", "description": "The name", "example": "demo"},
+			"flavor": {"type": "select", "options": ["a", "b"]}
+		},
+		"optional": {
+			"replicas": {"type": "int"}
+		}
+	}
+}`)
+	if err := ioutil.WriteFile(filepath.Join(dir, ConceptFileName), concept, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "readme.md"), []byte("# Described\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	desc, err := DescribeConcept(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if desc.Metadata.Description != "A described concept" {
+		t.Errorf("DescribeConcept() description = %q", desc.Metadata.Description)
+	}
+	if desc.Readme != "# Described" {
+		t.Errorf("DescribeConcept() readme = %q, want '# Described'", desc.Readme)
+	}
+
+	want := []struct {
+		id        string
+		mandatory bool
+	}{{"flavor", true}, {"name", true}, {"replicas", false}}
+	if len(desc.Inputs) != len(want) {
+		t.Fatalf("DescribeConcept() inputs = %v, want %d", desc.Inputs, len(want))
+	}
+	for i, w := range want {
+		if desc.Inputs[i].ID != w.id || desc.Inputs[i].Mandatory != w.mandatory {
+			t.Errorf("DescribeConcept() input %d = %s (mandatory %t), want %s (mandatory %t)", i, desc.Inputs[i].ID, desc.Inputs[i].Mandatory, w.id, w.mandatory)
+		}
+	}
+	if desc.Inputs[0].Options == nil || desc.Inputs[1].Example != "demo" {
+		t.Errorf("DescribeConcept() expected options and examples to be taken over")
+	}
+}
@@ -0,0 +1,161 @@
+package concepts
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/gobwas/glob"
+
+	"github.com/redradrat/kable/pkg/repositories"
+)
+
+// IndexedConcept is a concept listed by a repository, along with its metadata
+type IndexedConcept struct {
+	ID   ConceptIdentifier
+	Meta ConceptMeta
+}
+
+// DiscoverConcepts walks root for concept directories (containing a concept.json), and returns their paths relative
+// to root, filtered by the include and exclude globs of the index. vendor/ and dot-directories are skipped.
+func DiscoverConcepts(root string, idx repositories.RepoIndex) ([]string, error) {
+	include, err := compileGlobs(idx.Include)
+	if err != nil {
+		return nil, err
+	}
+	exclude, err := compileGlobs(idx.Exclude)
+	if err != nil {
+		return nil, err
+	}
+
+	var paths []string
+	err = filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			if path != root && (strings.HasPrefix(info.Name(), ".") || info.Name()+"/" == ConceptVendorDir) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if info.Name() != ConceptFileName {
+			return nil
+		}
+
+		rel, err := filepath.Rel(root, filepath.Dir(path))
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if (len(include) == 0 || matchesAny(include, rel)) && !matchesAny(exclude, rel) {
+			paths = append(paths, rel)
+		}
+		return nil
+	})
+	sort.Strings(paths)
+	return paths, err
+}
+
+func compileGlobs(patterns []string) ([]glob.Glob, error) {
+	var globs []glob.Glob
+	for _, pattern := range patterns {
+		g, err := glob.Compile(pattern, '/')
+		if err != nil {
+			return nil, fmt.Errorf("invalid glob '%s': %w", pattern, err)
+		}
+		globs = append(globs, g)
+	}
+	return globs, nil
+}
+
+func matchesAny(globs []glob.Glob, path string) bool {
+	for _, g := range globs {
+		if g.Match(path) {
+			return true
+		}
+	}
+	return false
+}
+
+// RepoConcepts returns the concept paths of the repository at path with the given index. Repositories in discovery
+// mode are walked, all others list their concepts in the index.
+func RepoConcepts(path string, idx repositories.RepoIndex) ([]string, error) {
+	if idx.Discover {
+		return DiscoverConcepts(path, idx)
+	}
+	return idx.ConceptEntries, nil
+}
+
+// IndexRepository walks the repository directory at path, and returns a static index of all concepts found, with
+// their metadata cached. The include and exclude globs of an existing index are kept for the next run.
+func IndexRepository(path string) (*repositories.RepoIndex, error) {
+	idx, err := repositories.ReadRepoIndex(path)
+	if os.IsNotExist(err) {
+		idx, err = &repositories.RepoIndex{Version: 1}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	paths, err := DiscoverConcepts(path, *idx)
+	if err != nil {
+		return nil, err
+	}
+	idx.Discover = false
+	idx.ConceptEntries = paths
+	idx.ConceptMeta = map[string]json.RawMessage{}
+	for _, p := range paths {
+		cpt, err := GetConcept(filepath.Join(path, filepath.FromSlash(p)))
+		if err != nil {
+			return nil, fmt.Errorf("unable to read concept '%s': %w", p, err)
+		}
+		meta, err := json.Marshal(cpt.Meta)
+		if err != nil {
+			return nil, err
+		}
+		idx.ConceptMeta[p] = meta
+	}
+	return idx, nil
+}
+
+// ListIndexedConcepts returns the concepts of all repositories with their metadata. Metadata cached in the index of
+// a repository is used as is, instead of reading the concept.
+func ListIndexedConcepts() ([]IndexedConcept, error) {
+	var out []IndexedConcept
+	repos, err := repositories.ListRepositories()
+	if err != nil {
+		return nil, err
+	}
+	for _, repo := range repos {
+		path, err := repo.AbsolutePath()
+		if err != nil {
+			return nil, err
+		}
+		idx, err := repositories.ReadRepoIndex(path)
+		if err != nil {
+			return nil, err
+		}
+		paths, err := RepoConcepts(path, *idx)
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range paths {
+			ic := IndexedConcept{ID: NewConceptIdentifier(p, repo.Name)}
+			if raw, ok := idx.ConceptMeta[p]; ok && json.Unmarshal(raw, &ic.Meta) == nil {
+				out = append(out, ic)
+				continue
+			}
+			cpt, err := GetConcept(filepath.Join(path, filepath.FromSlash(p)))
+			if err != nil {
+				return nil, fmt.Errorf("error getting concept '%s': %w", ic.ID, err)
+			}
+			ic.Meta = cpt.Meta
+			out = append(out, ic)
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,80 @@
+package concepts
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/redradrat/kable/pkg/repositories"
+)
+
+func conceptTree(t *testing.T) string {
+	root, err := ioutil.TempDir("", "kable-discovery")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, dir := range []string{"apps/grafana", "apps/loki", "infra/dns", "infra/dns/vendor/lib", ".git/x", "experimental/foo"} {
+		if err := os.MkdirAll(filepath.Join(root, dir), os.ModePerm); err != nil {
+			t.Fatal(err)
+		}
+		content := `{"apiVersion": 1, "type": "jsonnet", "metadata": {"name": "` + filepath.Base(dir) + `"}}`
+		if err := ioutil.WriteFile(filepath.Join(root, dir, ConceptFileName), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return root
+}
+
+func TestDiscoverConcepts(t *testing.T) {
+	root := conceptTree(t)
+	defer os.RemoveAll(root)
+
+	tests := []struct {
+		name    string
+		idx     repositories.RepoIndex
+		want    []string
+		wantErr bool
+	}{
+		{name: "all", want: []string{"apps/grafana", "apps/loki", "experimental/foo", "infra/dns"}},
+		{name: "include", idx: repositories.RepoIndex{Include: []string{"apps/*", "infra/**"}}, want: []string{"apps/grafana", "apps/loki", "infra/dns"}},
+		{name: "exclude", idx: repositories.RepoIndex{Exclude: []string{"experimental/**", "*/loki"}}, want: []string{"apps/grafana", "infra/dns"}},
+		{name: "invalid glob", idx: repositories.RepoIndex{Include: []string{"apps/[a"}}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := DiscoverConcepts(root, tt.idx)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("DiscoverConcepts() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("DiscoverConcepts() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIndexRepository(t *testing.T) {
+	root := conceptTree(t)
+	defer os.RemoveAll(root)
+	if err := repositories.WriteRepoIndex(root, repositories.RepoIndex{Version: 1, Exclude: []string{"experimental/**"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	idx, err := IndexRepository(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"apps/grafana", "apps/loki", "infra/dns"}; !reflect.DeepEqual(idx.ConceptEntries, want) {
+		t.Errorf("IndexRepository() concepts = %v, want %v", idx.ConceptEntries, want)
+	}
+	if len(idx.Exclude) != 1 {
+		t.Errorf("IndexRepository() dropped excludes of existing index")
+	}
+	meta := ConceptMeta{}
+	if err := json.Unmarshal(idx.ConceptMeta["apps/loki"], &meta); err != nil || meta.Name != "loki" {
+		t.Errorf("IndexRepository() meta = %s, want cached metadata", idx.ConceptMeta["apps/loki"])
+	}
+}
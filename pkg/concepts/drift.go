@@ -0,0 +1,143 @@
+package concepts
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+type DriftStatus string
+
+const (
+	DriftUpToDate       DriftStatus = "up-to-date"
+	DriftConceptChanged DriftStatus = "concept-changed"
+	DriftHandEdited     DriftStatus = "hand-edited"
+	DriftFailing        DriftStatus = "failing"
+)
+
+// DriftResult describes a single rendered instance. Files lists the paths, that differ from the expected output.
+type DriftResult struct {
+	Path    string      `json:"path"`
+	Concept string      `json:"concept,omitempty"`
+	Status  DriftStatus `json:"status"`
+	Files   []string    `json:"files,omitempty"`
+	Error   string      `json:"error,omitempty"`
+}
+
+type DriftReport struct {
+	Results []DriftResult `json:"results"`
+}
+
+// Drifted returns all results, that are not up to date
+func (dr DriftReport) Drifted() []DriftResult {
+	var out []DriftResult
+	for _, res := range dr.Results {
+		if res.Status != DriftUpToDate {
+			out = append(out, res)
+		}
+	}
+	return out
+}
+
+// DetectDrift finds all rendered instances below root, and compares their content with a fresh render from their
+// renderinfo. Instances, whose files don't match the digests recorded at render time, are reported as hand-edited.
+// Otherwise, instances whose fresh render differs are reported as concept-changed.
+//
+// Concepts from repositories are rendered from the local repository cache, which should be updated beforehand.
+func DetectDrift(root string) (*DriftReport, error) {
+	var dirs []string
+	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() && path != root && (strings.HasPrefix(info.Name(), ".") || info.Name()+"/" == ConceptVendorDir) {
+			return filepath.SkipDir
+		}
+		if !info.IsDir() && info.Name() == ConceptRenderFileName {
+			dirs = append(dirs, filepath.Dir(path))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	report := DriftReport{}
+	for _, dir := range dirs {
+		rel, err := filepath.Rel(root, dir)
+		if err != nil {
+			return nil, err
+		}
+		res := detectInstanceDrift(dir)
+		res.Path = rel
+		report.Results = append(report.Results, res)
+	}
+	return &report, nil
+}
+
+func detectInstanceDrift(dir string) DriftResult {
+	failing := func(res DriftResult, err error) DriftResult {
+		res.Status = DriftFailing
+		res.Error = err.Error()
+		return res
+	}
+
+	res := DriftResult{}
+	current, err := ReadRender(dir)
+	if err != nil {
+		return failing(res, err)
+	}
+	ri, err := current.RenderInfo()
+	if err != nil {
+		return failing(res, err)
+	}
+	res.Concept = ri.Concept
+	if ri.Concept == "" {
+		return failing(res, fmt.Errorf("renderinfo does not record its concept, re-render it with a current kable version"))
+	}
+
+	actual := current.FileDigests()
+	if ri.Files != nil {
+		if changed := diffDigests(ri.Files, actual); len(changed) != 0 {
+			res.Status = DriftHandEdited
+			res.Files = changed
+			return res
+		}
+	}
+
+	ttype := ri.Target.Type
+	if ttype == "" {
+		ttype = YamlTargetType
+	}
+	fresh, err := RenderConcept(ri.Concept, ri.RenderValues(), ttype, RenderOpts{Local: ri.Target.Local, Single: ri.Target.Single})
+	if err != nil {
+		return failing(res, err)
+	}
+	if changed := diffDigests(fresh.FileDigests(), actual); len(changed) != 0 {
+		res.Status = DriftConceptChanged
+		res.Files = changed
+		return res
+	}
+
+	res.Status = DriftUpToDate
+	return res
+}
+
+// diffDigests returns the sorted paths, that are missing in either, or differ between the given digests
+func diffDigests(want, got map[string]string) []string {
+	var out []string
+	for path, digest := range want {
+		if got[path] != digest {
+			out = append(out, path)
+		}
+	}
+	for path := range got {
+		if _, ok := want[path]; !ok {
+			out = append(out, path)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
@@ -0,0 +1,77 @@
+package concepts
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestDetectDrift(t *testing.T) {
+	defer withTempRenderCache(t)()
+	root, err := ioutil.TempDir("", "kable-drift")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+	conceptPath, err := filepath.Abs("../../e2e-test/testconcept1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, dir := range []string{"clean", "edited", "changed", "failing"} {
+		render, err := RenderConcept(conceptPath, &RenderValues{"instanceName": StringValueType(dir), "nameSelection": StringValueType("Option 1")}, YamlTargetType, RenderOpts{Local: true, Single: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := render.Write(filepath.Join(root, dir)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Hand edits change the rendered files
+	if err := ioutil.WriteFile(filepath.Join(root, "edited", "manifest.yaml"), []byte("edited"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	// Changed values stand in for a changed concept, as the fresh render differs from the untouched files
+	modifyRenderInfo(t, filepath.Join(root, "changed"), func(ri *RenderInfoV2) {
+		ri.Values["instanceName"] = StringValueType("renamed")
+	})
+	modifyRenderInfo(t, filepath.Join(root, "failing"), func(ri *RenderInfoV2) {
+		ri.Concept = filepath.Join(root, "does-not-exist")
+	})
+
+	report, err := DetectDrift(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := map[string]DriftStatus{}
+	for _, res := range report.Results {
+		got[res.Path] = res.Status
+	}
+	want := map[string]DriftStatus{
+		"clean":   DriftUpToDate,
+		"edited":  DriftHandEdited,
+		"changed": DriftConceptChanged,
+		"failing": DriftFailing,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("DetectDrift() = %v, want %v", got, want)
+	}
+	if len(report.Drifted()) != 3 {
+		t.Errorf("DetectDrift() drifted = %d, want 3", len(report.Drifted()))
+	}
+}
+
+func modifyRenderInfo(t *testing.T, dir string, modify func(ri *RenderInfoV2)) {
+	path := filepath.Join(dir, ConceptRenderFileName)
+	ri, err := ParseRenderInfoFromFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	modify(ri)
+	if err := WriteRenderInfoFile(path, ri); err != nil {
+		t.Fatal(err)
+	}
+}
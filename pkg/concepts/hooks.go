@@ -0,0 +1,153 @@
+package concepts
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/redradrat/kable/pkg/errors"
+)
+
+const (
+	DefaultHookTimeout = 5 * time.Minute
+	HookValueEnvPrefix = "KABLE_VALUE_"
+	HookConceptDirEnv  = "KABLE_CONCEPT_DIR"
+	HookRenderDirEnv   = "KABLE_RENDER_DIR"
+)
+
+var hookEnvInvalidChars = regexp.MustCompile("[^A-Z0-9_]")
+
+var (
+	conceptLocksMu sync.Mutex
+	conceptLocks   = map[string]*sync.Mutex{}
+)
+
+// ConceptHooks are commands, that are run around the evaluation of a concept.
+type ConceptHooks struct {
+	// PreRender runs before evaluation, e.g. to generate assets within the concept directory
+	PreRender *Hook `json:"preRender,omitempty"`
+	// PostRender runs after evaluation, with the rendered files in $KABLE_RENDER_DIR. Files created or changed there
+	// become part of the render.
+	PostRender *Hook `json:"postRender,omitempty"`
+}
+
+func (ch ConceptHooks) IsEmpty() bool {
+	return ch.PreRender == nil && ch.PostRender == nil
+}
+
+type Hook struct {
+	Command string `json:"command"`
+	// Timeout is a duration string, e.g. '30s'. Defaults to DefaultHookTimeout.
+	Timeout string `json:"timeout,omitempty"`
+}
+
+// Run executes the hook via 'sh -c' within dir. Values are exposed as $KABLE_VALUE_<NAME> environment variables, and
+// all output is streamed to out.
+func (h Hook) Run(dir string, avs *RenderValues, extraEnv []string, out io.Writer) error {
+	timeout := DefaultHookTimeout
+	if h.Timeout != "" {
+		var err error
+		if timeout, err = time.ParseDuration(h.Timeout); err != nil {
+			return fmt.Errorf("%w: invalid timeout '%s'", errors.HookFailedError, h.Timeout)
+		}
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "sh", "-c", h.Command)
+	cmd.Dir = dir
+	// Without a writer, output is discarded. Leaving the streams unset avoids waiting on pipes still held open by
+	// children of a killed hook.
+	if out != nil {
+		cmd.Stdout = out
+		cmd.Stderr = out
+	}
+	cmd.Env = append(append(os.Environ(), hookEnv(avs)...), HookConceptDirEnv+"="+dir)
+	cmd.Env = append(cmd.Env, extraEnv...)
+
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			return fmt.Errorf("%w: '%s' timed out after %s", errors.HookFailedError, h.Command, timeout)
+		}
+		return fmt.Errorf("%w: '%s': %v", errors.HookFailedError, h.Command, err)
+	}
+	return nil
+}
+
+// lockConceptDir serializes renders of the concept at path within this process, as its hooks work within the shared
+// concept directory. It returns the function releasing the lock.
+func lockConceptDir(path string) (func(), error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	conceptLocksMu.Lock()
+	l, ok := conceptLocks[abs]
+	if !ok {
+		l = &sync.Mutex{}
+		conceptLocks[abs] = l
+	}
+	conceptLocksMu.Unlock()
+
+	l.Lock()
+	return l.Unlock, nil
+}
+
+func hookEnv(avs *RenderValues) []string {
+	var env []string
+	if avs == nil {
+		return env
+	}
+	for k, v := range *avs {
+		name := hookEnvInvalidChars.ReplaceAllString(strings.ToUpper(k), "_")
+		env = append(env, HookValueEnvPrefix+name+"="+v.String())
+	}
+	return env
+}
+
+// runPostRenderHook writes the rendered files to a temporary directory, runs the hook against it, and replaces the
+// files of the render with the content of the directory afterwards.
+func runPostRenderHook(h Hook, path string, avs *RenderValues, render *Render, out io.Writer) error {
+	dir, err := ioutil.TempDir("", "kable-render")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+
+	if err := render.WriteFiles(dir); err != nil {
+		return err
+	}
+	if err := h.Run(path, avs, []string{HookRenderDirEnv + "=" + dir}, out); err != nil {
+		return err
+	}
+
+	var files []File
+	err = filepath.Walk(dir, func(p string, info os.FileInfo, err error) error {
+		if err != nil || !info.Mode().IsRegular() {
+			return err
+		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		content, err := ioutil.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		files = append(files, File{path: filepath.ToSlash(rel), content: content})
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	render.Files = files
+	return nil
+}
@@ -0,0 +1,108 @@
+package concepts
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+const hookConceptMain = `{
+  apiVersion: "v1",
+  kind: "ConfigMap",
+  metadata: { name: std.extVar("instanceName") },
+  data: { generated: importstr "generated.txt" },
+}
+`
+
+func writeHookConcept(t *testing.T, hooks string) string {
+	dir, err := ioutil.TempDir("", "kable-hooks")
+	if err != nil {
+		t.Fatal(err)
+	}
+	concept := `{"apiVersion": 1, "type": "jsonnet", "metadata": {"name": "hooks"}, "inputs": {"mandatory": {"instanceName": {"type": "string"}}}, "hooks": ` + hooks + `}`
+	for name, content := range map[string]string{ConceptFileName: concept, ConceptMainJsonnet: hookConceptMain, ConceptJsonnetfile: "{}"} {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestRenderConcept_Hooks(t *testing.T) {
+	dir := writeHookConcept(t, `{
+		"preRender": {"command": "echo pre; printf $KABLE_VALUE_INSTANCENAME > generated.txt"},
+		"postRender": {"command": "echo post; echo extra > $KABLE_RENDER_DIR/extra.txt"}
+	}`)
+	defer os.RemoveAll(dir)
+
+	var out bytes.Buffer
+	render, err := RenderConcept(dir, &RenderValues{"instanceName": StringValueType("hooked")}, YamlTargetType, RenderOpts{Local: true, HookOutput: &out})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := out.String(); got != "pre\npost\n" {
+		t.Errorf("RenderConcept() hook output = %q, want %q", got, "pre\npost\n")
+	}
+	if !strings.Contains(render.PrintFiles(), "generated: hooked") {
+		t.Errorf("RenderConcept() expected pre-render hook to generate assets, got: %s", render.PrintFiles())
+	}
+	var found bool
+	for _, f := range render.Files {
+		found = found || f.path == "extra.txt"
+	}
+	if !found {
+		t.Errorf("RenderConcept() expected post-render hook to add 'extra.txt'")
+	}
+}
+
+func TestRenderConcept_HooksConcurrent(t *testing.T) {
+	dir := writeHookConcept(t, `{"preRender": {"command": "printf $KABLE_VALUE_INSTANCENAME > generated.txt; sleep 0.1"}}`)
+	defer os.RemoveAll(dir)
+
+	// Every render has to evaluate the assets generated by its own pre-render hook
+	var wg sync.WaitGroup
+	names := []string{"first", "second", "third"}
+	renders := make([]*Render, len(names))
+	errs := make([]error, len(names))
+	for i, name := range names {
+		wg.Add(1)
+		go func(i int, name string) {
+			defer wg.Done()
+			renders[i], errs[i] = RenderConcept(dir, &RenderValues{"instanceName": StringValueType(name)}, YamlTargetType, RenderOpts{Local: true})
+		}(i, name)
+	}
+	wg.Wait()
+
+	for i, name := range names {
+		if errs[i] != nil {
+			t.Fatal(errs[i])
+		}
+		if !strings.Contains(renders[i].PrintFiles(), "generated: "+name) {
+			t.Errorf("RenderConcept() expected assets generated for '%s', got: %s", name, renders[i].PrintFiles())
+		}
+	}
+}
+
+func TestHook_Run(t *testing.T) {
+	tests := []struct {
+		name    string
+		hook    Hook
+		wantErr bool
+	}{
+		{name: "success", hook: Hook{Command: "true"}},
+		{name: "failure", hook: Hook{Command: "exit 1"}, wantErr: true},
+		{name: "timeout", hook: Hook{Command: "sleep 5", Timeout: "50ms"}, wantErr: true},
+		{name: "invalid timeout", hook: Hook{Command: "true", Timeout: "soon"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.hook.Run(os.TempDir(), nil, nil, nil); (err != nil) != tt.wantErr {
+				t.Errorf("Run() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
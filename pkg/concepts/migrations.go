@@ -0,0 +1,82 @@
+package concepts
+
+import "sort"
+
+// ConceptMigrations are declared by a concept, to carry values of existing renders over to changed inputs.
+type ConceptMigrations struct {
+	// Renamed maps old input names to their new names
+	Renamed map[string]string `json:"renamed,omitempty"`
+	// Removed lists inputs, whose values are dropped
+	Removed []string `json:"removed,omitempty"`
+	// ValueMappings maps (new) input names to a mapping of old to new select options
+	ValueMappings map[string]map[string]string `json:"valueMappings,omitempty"`
+}
+
+// MigrationReport records which values have been migrated during a render.
+type MigrationReport struct {
+	Renamed map[string]string `json:"renamed,omitempty"`
+	Removed []string          `json:"removed,omitempty"`
+	Mapped  map[string]string `json:"mapped,omitempty"`
+}
+
+func (mr MigrationReport) IsEmpty() bool {
+	return len(mr.Renamed) == 0 && len(mr.Removed) == 0 && len(mr.Mapped) == 0
+}
+
+// Apply migrates the given values and returns them as a copy. Renames are applied first, then removals, and finally
+// value mappings, so mappings refer to the new input names. The report is nil, if nothing had to be migrated.
+func (cm ConceptMigrations) Apply(avs *RenderValues) (*RenderValues, *MigrationReport) {
+	if avs == nil {
+		return nil, nil
+	}
+	out := RenderValues{}
+	for k, v := range *avs {
+		out[k] = v
+	}
+	report := MigrationReport{Renamed: map[string]string{}, Mapped: map[string]string{}}
+
+	// Renames are applied at once to the original values, so chained renames (a -> b, b -> c) and swaps don't depend
+	// on their order
+	var renamed []string
+	for from := range cm.Renamed {
+		if _, ok := out[from]; ok {
+			renamed = append(renamed, from)
+			delete(out, from)
+		}
+	}
+	sort.Strings(renamed)
+	for _, from := range renamed {
+		to := cm.Renamed[from]
+		// A value already given for the new name takes precedence
+		if _, exists := out[to]; exists {
+			report.Removed = append(report.Removed, from)
+			continue
+		}
+		out[to] = (*avs)[from]
+		report.Renamed[from] = to
+	}
+
+	for _, k := range cm.Removed {
+		if _, ok := out[k]; ok {
+			delete(out, k)
+			report.Removed = append(report.Removed, k)
+		}
+	}
+	sort.Strings(report.Removed)
+
+	for k, mapping := range cm.ValueMappings {
+		v, ok := out[k].(StringValueType)
+		if !ok {
+			continue
+		}
+		if mapped, ok := mapping[v.String()]; ok {
+			out[k] = StringValueType(mapped)
+			report.Mapped[k] = v.String() + " -> " + mapped
+		}
+	}
+
+	if report.IsEmpty() {
+		return &out, nil
+	}
+	return &out, &report
+}
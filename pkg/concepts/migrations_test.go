@@ -0,0 +1,92 @@
+package concepts
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestConceptMigrations_Apply(t *testing.T) {
+	migrations := ConceptMigrations{
+		Renamed:       map[string]string{"instanceName": "name", "old": "new"},
+		Removed:       []string{"legacy"},
+		ValueMappings: map[string]map[string]string{"selection": {"Option 1": "Option A"}},
+	}
+	tests := []struct {
+		name       string
+		values     *RenderValues
+		want       *RenderValues
+		wantReport *MigrationReport
+	}{
+		{
+			name:   "nothing to migrate",
+			values: &RenderValues{"name": StringValueType("a"), "selection": StringValueType("Option B")},
+			want:   &RenderValues{"name": StringValueType("a"), "selection": StringValueType("Option B")},
+		},
+		{
+			name:   "all migrations",
+			values: &RenderValues{"instanceName": StringValueType("a"), "legacy": IntValueType(1), "selection": StringValueType("Option 1")},
+			want:   &RenderValues{"name": StringValueType("a"), "selection": StringValueType("Option A")},
+			wantReport: &MigrationReport{
+				Renamed: map[string]string{"instanceName": "name"},
+				Removed: []string{"legacy"},
+				Mapped:  map[string]string{"selection": "Option 1 -> Option A"},
+			},
+		},
+		{
+			name:       "new name takes precedence",
+			values:     &RenderValues{"old": StringValueType("a"), "new": StringValueType("b")},
+			want:       &RenderValues{"new": StringValueType("b")},
+			wantReport: &MigrationReport{Renamed: map[string]string{}, Removed: []string{"old"}, Mapped: map[string]string{}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, report := migrations.Apply(tt.values)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Apply() got = %v, want %v", got, tt.want)
+			}
+			if !reflect.DeepEqual(report, tt.wantReport) {
+				t.Errorf("Apply() report = %+v, want %+v", report, tt.wantReport)
+			}
+		})
+	}
+}
+
+func TestConceptMigrations_Apply_chained(t *testing.T) {
+	tests := []struct {
+		name    string
+		renamed map[string]string
+		values  *RenderValues
+		want    *RenderValues
+	}{
+		{
+			name:    "chain",
+			renamed: map[string]string{"a": "b", "b": "c"},
+			values:  &RenderValues{"a": StringValueType("1"), "b": StringValueType("2")},
+			want:    &RenderValues{"b": StringValueType("1"), "c": StringValueType("2")},
+		},
+		{
+			name:    "chain with only the first value",
+			renamed: map[string]string{"a": "b", "b": "c"},
+			values:  &RenderValues{"a": StringValueType("1")},
+			want:    &RenderValues{"b": StringValueType("1")},
+		},
+		{
+			name:    "swap",
+			renamed: map[string]string{"a": "b", "b": "a"},
+			values:  &RenderValues{"a": StringValueType("1"), "b": StringValueType("2")},
+			want:    &RenderValues{"a": StringValueType("2"), "b": StringValueType("1")},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Map iteration order is random, so a single run could pass by chance
+			for i := 0; i < 20; i++ {
+				got, _ := ConceptMigrations{Renamed: tt.renamed}.Apply(tt.values)
+				if !reflect.DeepEqual(got, tt.want) {
+					t.Fatalf("Apply() got = %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,91 @@
+package concepts
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/redradrat/kable/pkg/errors"
+)
+
+const (
+	ValuesFileName  = "kable.values.json"
+	BaseValuesLayer = "base"
+)
+
+// ValuesOverlays defines model for a values file, consisting of a base layer and per-environment overrides.
+type ValuesOverlays struct {
+	Version      int                      `json:"version"`
+	Base         *RenderValues            `json:"base,omitempty"`
+	Environments map[string]*RenderValues `json:"environments,omitempty"`
+}
+
+func ParseValuesOverlaysFromFile(path string) (*ValuesOverlays, error) {
+	f, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	vo := &ValuesOverlays{}
+	if err := json.Unmarshal(f, vo); err != nil {
+		return nil, err
+	}
+
+	return vo, nil
+}
+
+// ValueLayers records for each value, which layers contributed to it, in the order they were applied.
+type ValueLayers map[string][]string
+
+// Resolve merges the base layer and the given environments in order. Later layers override earlier ones, except for
+// maps, which are merged deeply.
+func (vo ValuesOverlays) Resolve(envs ...string) (*RenderValues, ValueLayers, error) {
+	values := RenderValues{}
+	layers := ValueLayers{}
+
+	if vo.Base != nil {
+		mergeValuesLayer(values, layers, *vo.Base, BaseValuesLayer)
+	}
+	for _, env := range envs {
+		overlay, ok := vo.Environments[env]
+		if !ok {
+			return nil, nil, fmt.Errorf("%w: '%s'", errors.UnknownEnvironmentError, env)
+		}
+		if overlay != nil {
+			mergeValuesLayer(values, layers, *overlay, env)
+		}
+	}
+
+	return &values, layers, nil
+}
+
+func mergeValuesLayer(values RenderValues, layers ValueLayers, overlay RenderValues, layer string) {
+	for k, v := range overlay {
+		existingMap, existingIsMap := values[k].(MapValueType)
+		overlayMap, overlayIsMap := v.(MapValueType)
+		if existingIsMap && overlayIsMap {
+			values[k] = MapValueType(deepMergeMaps(existingMap, overlayMap))
+			layers[k] = append(layers[k], layer)
+			continue
+		}
+		values[k] = v
+		layers[k] = []string{layer}
+	}
+}
+
+func deepMergeMaps(base, overlay map[string]interface{}) map[string]interface{} {
+	out := make(map[string]interface{}, len(base))
+	for k, v := range base {
+		out[k] = v
+	}
+	for k, v := range overlay {
+		baseMap, baseIsMap := out[k].(map[string]interface{})
+		overlayMap, overlayIsMap := v.(map[string]interface{})
+		if baseIsMap && overlayIsMap {
+			out[k] = deepMergeMaps(baseMap, overlayMap)
+			continue
+		}
+		out[k] = v
+	}
+	return out
+}
@@ -0,0 +1,81 @@
+package concepts
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestValuesOverlays_Resolve(t *testing.T) {
+	overlays := ValuesOverlays{
+		Version: 1,
+		Base: &RenderValues{
+			"instanceName": StringValueType("grafana"),
+			"replicas":     IntValueType(1),
+			"resources":    MapValueType{"limits": map[string]interface{}{"cpu": "1", "memory": "1Gi"}},
+		},
+		Environments: map[string]*RenderValues{
+			"staging": {"replicas": IntValueType(2)},
+			"prod": {
+				"replicas":  IntValueType(3),
+				"resources": MapValueType{"limits": map[string]interface{}{"memory": "4Gi"}},
+			},
+		},
+	}
+
+	tests := []struct {
+		name       string
+		envs       []string
+		wantValues RenderValues
+		wantLayers ValueLayers
+		wantErr    bool
+	}{
+		{
+			name: "base only",
+			wantValues: RenderValues{
+				"instanceName": StringValueType("grafana"),
+				"replicas":     IntValueType(1),
+				"resources":    MapValueType{"limits": map[string]interface{}{"cpu": "1", "memory": "1Gi"}},
+			},
+			wantLayers: ValueLayers{"instanceName": {"base"}, "replicas": {"base"}, "resources": {"base"}},
+		},
+		{
+			name: "prod",
+			envs: []string{"prod"},
+			wantValues: RenderValues{
+				"instanceName": StringValueType("grafana"),
+				"replicas":     IntValueType(3),
+				"resources":    MapValueType{"limits": map[string]interface{}{"cpu": "1", "memory": "4Gi"}},
+			},
+			wantLayers: ValueLayers{"instanceName": {"base"}, "replicas": {"prod"}, "resources": {"base", "prod"}},
+		},
+		{
+			name: "staging then prod",
+			envs: []string{"staging", "prod"},
+			wantValues: RenderValues{
+				"instanceName": StringValueType("grafana"),
+				"replicas":     IntValueType(3),
+				"resources":    MapValueType{"limits": map[string]interface{}{"cpu": "1", "memory": "4Gi"}},
+			},
+			wantLayers: ValueLayers{"instanceName": {"base"}, "replicas": {"prod"}, "resources": {"base", "prod"}},
+		},
+		{name: "unknown", envs: []string{"qa"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			values, layers, err := overlays.Resolve(tt.envs...)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Resolve() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+			if !reflect.DeepEqual(*values, tt.wantValues) {
+				t.Errorf("Resolve() values = %v, want %v", *values, tt.wantValues)
+			}
+			if !reflect.DeepEqual(layers, tt.wantLayers) {
+				t.Errorf("Resolve() layers = %v, want %v", layers, tt.wantLayers)
+			}
+		})
+	}
+}
@@ -0,0 +1,234 @@
+package concepts
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/redradrat/kable/pkg/repositories"
+)
+
+const (
+	ProjectFileName        = "kable.project.json"
+	DefaultSyncConcurrency = 4
+)
+
+// Project defines model for a project file, declaring many concept instances to be rendered at once.
+type Project struct {
+	Version   int               `json:"version"`
+	Instances []ProjectInstance `json:"instances"`
+}
+
+// ProjectInstance defines a single rendered instance of a concept within a Project.
+type ProjectInstance struct {
+	Concept    string        `json:"concept"`
+	Output     string        `json:"output"`
+	TargetType TargetType    `json:"targetType,omitempty"`
+	Single     bool          `json:"single,omitempty"`
+	Local      bool          `json:"local,omitempty"`
+	Values     *RenderValues `json:"values,omitempty"`
+}
+
+func ParseProjectFromFile(path string) (*Project, error) {
+	f, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &Project{}
+	if err := json.Unmarshal(f, p); err != nil {
+		return nil, err
+	}
+
+	return p, nil
+}
+
+// Validate checks that every instance has a unique output inside the project directory.
+func (p Project) Validate() error {
+	outputs := map[string]string{}
+	for _, instance := range p.Instances {
+		if instance.Concept == "" {
+			return fmt.Errorf("instance with output '%s' has no concept", instance.Output)
+		}
+		out := filepath.Clean(instance.Output)
+		if filepath.IsAbs(out) || out == ".." || strings.HasPrefix(out, ".."+string(filepath.Separator)) {
+			return fmt.Errorf("output '%s' of instance '%s' is outside of the project directory", instance.Output, instance.Concept)
+		}
+		if other, ok := outputs[out]; ok {
+			return fmt.Errorf("instances '%s' and '%s' share the same output '%s'", other, instance.Concept, instance.Output)
+		}
+		outputs[out] = instance.Concept
+	}
+	return nil
+}
+
+type SyncOpts struct {
+	Concurrency int
+	Prune       bool
+}
+
+// SyncResult is the outcome of rendering a single ProjectInstance.
+type SyncResult struct {
+	Instance ProjectInstance
+	Err      error
+}
+
+type SyncReport struct {
+	Results []SyncResult
+	Pruned  []string
+}
+
+// Failed returns the results of all instances that could not be synced.
+func (sr SyncReport) Failed() []SyncResult {
+	var out []SyncResult
+	for _, res := range sr.Results {
+		if res.Err != nil {
+			out = append(out, res)
+		}
+	}
+	return out
+}
+
+// SyncProject renders all instances of the given project into their output directories, relative to root. Instances
+// are rendered concurrently, and a failing instance does not stop the others.
+func SyncProject(p Project, root string, opts SyncOpts) (*SyncReport, error) {
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+	if opts.Concurrency < 1 {
+		opts.Concurrency = DefaultSyncConcurrency
+	}
+
+	warmRepositoryCaches(p)
+
+	report := &SyncReport{Results: make([]SyncResult, len(p.Instances))}
+	jobs := make(chan int)
+	wg := sync.WaitGroup{}
+	for w := 0; w < opts.Concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				instance := p.Instances[i]
+				report.Results[i] = SyncResult{
+					Instance: instance,
+					Err:      syncInstance(instance, root),
+				}
+			}
+		}()
+	}
+	for i := range p.Instances {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	if opts.Prune {
+		pruned, err := pruneProject(p, root)
+		if err != nil {
+			return report, err
+		}
+		report.Pruned = pruned
+	}
+
+	return report, nil
+}
+
+// warmRepositoryCaches makes sure all referenced repositories are cloned before rendering concurrently, so workers
+// don't race for the same checkout. Errors are surfaced by the individual renders.
+func warmRepositoryCaches(p Project) {
+	seen := map[string]bool{}
+	for _, instance := range p.Instances {
+		if instance.Local || !IsValidConceptIdentifier(instance.Concept) {
+			continue
+		}
+		repoName := ConceptIdentifier(instance.Concept).Repo()
+		if seen[repoName] {
+			continue
+		}
+		seen[repoName] = true
+		if r, err := repositories.GetRepository(repoName); err == nil {
+			_, _ = r.AbsolutePath()
+		}
+	}
+}
+
+func syncInstance(instance ProjectInstance, root string) error {
+	ttype := instance.TargetType
+	if ttype == "" {
+		ttype = YamlTargetType
+	}
+	id := instance.Concept
+	if instance.Local && !filepath.IsAbs(id) {
+		id = filepath.Join(root, id)
+	}
+
+	bundle, err := RenderConcept(id, instance.Values, ttype, RenderOpts{
+		Local:           instance.Local,
+		WriteRenderInfo: true,
+		Single:          instance.Single,
+	})
+	if err != nil {
+		return err
+	}
+	return bundle.Write(filepath.Join(root, instance.Output))
+}
+
+// pruneProject removes all rendered output directories below root, that are not declared by the project anymore.
+func pruneProject(p Project, root string) ([]string, error) {
+	declared := map[string]bool{}
+	for _, instance := range p.Instances {
+		declared[filepath.Clean(instance.Output)] = true
+	}
+
+	var candidates []string
+	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() && path != root && (strings.HasPrefix(info.Name(), ".") || info.Name()+"/" == ConceptVendorDir) {
+			return filepath.SkipDir
+		}
+		if info.IsDir() || info.Name() != ConceptRenderFileName {
+			return nil
+		}
+		rel, err := filepath.Rel(root, filepath.Dir(path))
+		if err != nil {
+			return err
+		}
+		if !declared[rel] {
+			candidates = append(candidates, rel)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var pruned []string
+	for _, candidate := range candidates {
+		if candidate == "." || containsDeclaredOutput(candidate, declared) {
+			continue
+		}
+		if err := os.RemoveAll(filepath.Join(root, candidate)); err != nil {
+			return pruned, err
+		}
+		pruned = append(pruned, candidate)
+	}
+	sort.Strings(pruned)
+	return pruned, nil
+}
+
+func containsDeclaredOutput(dir string, declared map[string]bool) bool {
+	for out := range declared {
+		if strings.HasPrefix(out, dir+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
+}
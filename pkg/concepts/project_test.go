@@ -0,0 +1,79 @@
+package concepts
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestProject_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		project Project
+		wantErr bool
+	}{
+		{name: "valid", project: Project{Instances: []ProjectInstance{{Concept: "a@b", Output: "a"}, {Concept: "a@b", Output: "b"}}}},
+		{name: "duplicate output", project: Project{Instances: []ProjectInstance{{Concept: "a@b", Output: "a"}, {Concept: "c@b", Output: "a/"}}}, wantErr: true},
+		{name: "outside", project: Project{Instances: []ProjectInstance{{Concept: "a@b", Output: "../a"}}}, wantErr: true},
+		{name: "absolute", project: Project{Instances: []ProjectInstance{{Concept: "a@b", Output: "/a"}}}, wantErr: true},
+		{name: "no concept", project: Project{Instances: []ProjectInstance{{Output: "a"}}}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.project.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSyncProject(t *testing.T) {
+	defer withTempRenderCache(t)()
+	root, err := ioutil.TempDir("", "kable-project")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+	conceptPath, err := filepath.Abs("../../e2e-test/testconcept1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// An undeclared, previously rendered instance, that should get pruned
+	stale := filepath.Join(root, "stale")
+	if err := os.MkdirAll(stale, os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(stale, ConceptRenderFileName), []byte("{}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	project := Project{
+		Version: 1,
+		Instances: []ProjectInstance{
+			{Concept: conceptPath, Output: "dev", Local: true, Values: &RenderValues{"instanceName": StringValueType("dev"), "nameSelection": StringValueType("Option 1")}},
+			{Concept: conceptPath, Output: "prod", Local: true, Single: true, Values: &RenderValues{"instanceName": StringValueType("prod"), "nameSelection": StringValueType("Option 2")}},
+			{Concept: "does/not-exist@nowhere", Output: "broken"},
+		},
+	}
+
+	report, err := SyncProject(project, root, SyncOpts{Concurrency: 2, Prune: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if failed := report.Failed(); len(failed) != 1 || failed[0].Instance.Output != "broken" {
+		t.Errorf("SyncProject() failed = %v, want only 'broken'", failed)
+	}
+	for _, path := range []string{"dev/" + ConceptRenderFileName, "prod/" + ConceptRenderFileName, "prod/manifest.yaml"} {
+		if _, err := os.Stat(filepath.Join(root, path)); err != nil {
+			t.Errorf("SyncProject() expected '%s' to exist: %v", path, err)
+		}
+	}
+	if len(report.Pruned) != 1 || report.Pruned[0] != "stale" {
+		t.Errorf("SyncProject() pruned = %v, want [stale]", report.Pruned)
+	}
+	if _, err := os.Stat(stale); !os.IsNotExist(err) {
+		t.Errorf("SyncProject() expected stale output to be removed")
+	}
+}
@@ -0,0 +1,274 @@
+package concepts
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/redradrat/kable/pkg/errors"
+)
+
+const (
+	RenderInfoVersionV1 = 1
+	RenderInfoVersionV2 = 2
+)
+
+// RenderInfoV2 defines model for RenderInfoV2.
+type RenderInfoV2 struct {
+	Version      int               `json:"version"`
+	Meta         RenderMetaV2      `json:"meta"`
+	Concept      string            `json:"concept,omitempty"`
+	Target       RenderTarget      `json:"target"`
+	Origin       *ConceptOrigin    `json:"origin,omitempty"`
+	Values       TypedRenderValues `json:"values,omitempty"`
+	Environments []string          `json:"environments,omitempty"`
+	Layers       ValueLayers       `json:"layers,omitempty"`
+	Migrations   *MigrationReport  `json:"migrations,omitempty"`
+	// Files records the sha256 digest of each rendered file, to detect changes made after rendering
+	Files map[string]string `json:"files,omitempty"`
+}
+
+// RenderMetaV2 defines model for RenderMetaV2.
+type RenderMetaV2 struct {
+	DateCreated  time.Time `json:"date"`
+	KableVersion string    `json:"kableVersion,omitempty"`
+}
+
+// RenderTarget records the target type and options a render has been created with.
+type RenderTarget struct {
+	Type   TargetType `json:"type"`
+	Single bool       `json:"single,omitempty"`
+	Local  bool       `json:"local,omitempty"`
+}
+
+func NewRenderV2(avs *RenderValues, origin *ConceptOrigin, concept string, target RenderTarget) (*RenderInfoV2, error) {
+	render := RenderInfoV2{
+		Version: RenderInfoVersionV2,
+		Meta: RenderMetaV2{
+			DateCreated:  time.Now().Truncate(time.Second),
+			KableVersion: KableVersion,
+		},
+		Concept: concept,
+		Target:  target,
+		Origin:  origin,
+	}
+	if avs != nil {
+		render.Values = TypedRenderValues(*avs)
+	}
+
+	return &render, nil
+}
+
+// RenderValues returns the untyped values of this renderinfo, as they are passed to rendering.
+func (ri RenderInfoV2) RenderValues() *RenderValues {
+	out := RenderValues{}
+	for k, v := range ri.Values {
+		out[k] = v
+	}
+	return &out
+}
+
+// ValuesDigest returns a short, stable digest of the (typed) values of this renderinfo
+func (ri RenderInfoV2) ValuesDigest() (string, error) {
+	b, err := json.Marshal(ri.Values)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])[:12], nil
+}
+
+// MigrateRenderInfoV1 upgrades the given RenderInfoV1 to a RenderInfoV2. Information not recorded by v1, like the
+// concept identifier, is left empty. It fails, if the creation date can't be carried over.
+func MigrateRenderInfoV1(v1 RenderInfoV1) (*RenderInfoV2, error) {
+	date, err := parseRenderInfoV1Date(v1.Meta.DateCreated)
+	if err != nil {
+		return nil, err
+	}
+	v2 := &RenderInfoV2{
+		Version: RenderInfoVersionV2,
+		Meta: RenderMetaV2{
+			DateCreated: date,
+		},
+		Target:       RenderTarget{Type: YamlTargetType},
+		Origin:       v1.Origin,
+		Environments: v1.Environments,
+		Layers:       v1.Layers,
+	}
+	if v1.Values != nil {
+		v2.Values = TypedRenderValues(*v1.Values)
+	}
+	return v2, nil
+}
+
+// parseRenderInfoV1Date parses the RFC822 dates of RenderInfoV1. Zone abbreviations are ambiguous, so they are only
+// honored if they are known locally, otherwise the date is assumed to be UTC. A missing date is returned as zero time.
+func parseRenderInfoV1Date(date string) (time.Time, error) {
+	if date == "" {
+		return time.Time{}, nil
+	}
+	for _, layout := range []string{time.RFC822, time.RFC822Z} {
+		if t, err := time.Parse(layout, date); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid creation date '%s' (expected RFC822)", date)
+}
+
+type renderInfoVersion struct {
+	Version int `json:"version"`
+}
+
+// ParseRenderInfo parses a renderinfo of any known version, and returns it as RenderInfoV2.
+func ParseRenderInfo(content []byte) (*RenderInfoV2, error) {
+	version := renderInfoVersion{}
+	if err := json.Unmarshal(content, &version); err != nil {
+		return nil, err
+	}
+
+	switch version.Version {
+	case RenderInfoVersionV1:
+		v1 := RenderInfoV1{Values: &RenderValues{}}
+		if err := json.Unmarshal(content, &v1); err != nil {
+			return nil, err
+		}
+		return MigrateRenderInfoV1(v1)
+	case RenderInfoVersionV2:
+		v2 := RenderInfoV2{}
+		if err := json.Unmarshal(content, &v2); err != nil {
+			return nil, err
+		}
+		return &v2, nil
+	default:
+		return nil, fmt.Errorf("%w: %d", errors.RenderInfoVersionUnsupportedError, version.Version)
+	}
+}
+
+// ParseRenderInfoFromFile reads the renderinfo at path. Older versions are migrated transparently.
+func ParseRenderInfoFromFile(path string) (*RenderInfoV2, error) {
+	f, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	ri, err := ParseRenderInfo(f)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse '%s': %w", path, err)
+	}
+	return ri, nil
+}
+
+func WriteRenderInfoFile(path string, ri *RenderInfoV2) error {
+	out, err := json.MarshalIndent(ri, "", "	")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, out, 0666)
+}
+
+// MigrateRenderInfoTree upgrades all outdated renderinfo files below root, and returns their paths. With dryRun the
+// files are only checked, not written.
+func MigrateRenderInfoTree(root string, dryRun bool) ([]string, error) {
+	var migrated []string
+	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			if path != root && (strings.HasPrefix(info.Name(), ".") || info.Name()+"/" == ConceptVendorDir) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if info.Name() != ConceptRenderFileName {
+			return nil
+		}
+
+		content, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		version := renderInfoVersion{}
+		if err := json.Unmarshal(content, &version); err != nil {
+			return fmt.Errorf("unable to parse '%s': %w", path, err)
+		}
+		if version.Version == RenderInfoVersionV2 {
+			return nil
+		}
+		ri, err := ParseRenderInfo(content)
+		if err != nil {
+			return fmt.Errorf("unable to parse '%s': %w", path, err)
+		}
+		if !dryRun {
+			if err := WriteRenderInfoFile(path, ri); err != nil {
+				return err
+			}
+		}
+		migrated = append(migrated, path)
+		return nil
+	})
+	return migrated, err
+}
+
+// TypedRenderValues are RenderValues, that keep their type information when marshalled, so they survive a round trip
+// through JSON unchanged.
+type TypedRenderValues RenderValues
+
+type typedValue struct {
+	Type  ValueTypeIdentifier `json:"type"`
+	Value json.RawMessage     `json:"value"`
+}
+
+func (tv TypedRenderValues) MarshalJSON() ([]byte, error) {
+	out := map[string]typedValue{}
+	for k, v := range tv {
+		raw, err := json.Marshal(v)
+		if err != nil {
+			return nil, err
+		}
+		out[k] = typedValue{Type: ValueTypeIdentifier(v.ValueTypeIdentifier()), Value: raw}
+	}
+	return json.Marshal(out)
+}
+
+func (tv *TypedRenderValues) UnmarshalJSON(bytes []byte) error {
+	inter := map[string]typedValue{}
+	if err := json.Unmarshal(bytes, &inter); err != nil {
+		return err
+	}
+
+	out := TypedRenderValues{}
+	for k, v := range inter {
+		var err error
+		switch v.Type {
+		case RenderStringValueTypeIdentifier:
+			var val StringValueType
+			err = json.Unmarshal(v.Value, &val)
+			out[k] = val
+		case RenderMapValueTypeIdentifier:
+			var val MapValueType
+			err = json.Unmarshal(v.Value, &val)
+			out[k] = val
+		case RenderIntValueTypeIdentifier:
+			var val IntValueType
+			err = json.Unmarshal(v.Value, &val)
+			out[k] = val
+		case RenderBoolValueTypeIdentifier:
+			var val BoolValueType
+			err = json.Unmarshal(v.Value, &val)
+			out[k] = val
+		default:
+			return fmt.Errorf("%w: '%s' of value '%s'", errors.ValueTypeNotSupported, v.Type, k)
+		}
+		if err != nil {
+			return fmt.Errorf("unable to parse value '%s': %w", k, err)
+		}
+	}
+	*tv = out
+	return nil
+}
@@ -0,0 +1,132 @@
+package concepts
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+const renderInfoV1Fixture = `{
+	"version": 1,
+	"meta": {"date": "17 Oct 20 17:42 UTC"},
+	"origin": {"repository": "https://github.com/redradrat/demo-concepts", "ref": "refs/heads/master"},
+	"values": {"instanceName": "test", "replicas": 3, "enabled": true, "labels": {"a": "b"}}
+}`
+
+func TestParseRenderInfo(t *testing.T) {
+	wantValues := TypedRenderValues{
+		"instanceName": StringValueType("test"),
+		"replicas":     IntValueType(3),
+		"enabled":      BoolValueType(true),
+		"labels":       MapValueType{"a": "b"},
+	}
+
+	v1, err := ParseRenderInfo([]byte(renderInfoV1Fixture))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v1.Version != RenderInfoVersionV2 {
+		t.Errorf("ParseRenderInfo() version = %d, want %d", v1.Version, RenderInfoVersionV2)
+	}
+	if want := time.Date(2020, 10, 17, 17, 42, 0, 0, time.UTC); !v1.Meta.DateCreated.Equal(want) {
+		t.Errorf("ParseRenderInfo() date = %v, want %v", v1.Meta.DateCreated, want)
+	}
+	if !reflect.DeepEqual(v1.Values, wantValues) {
+		t.Errorf("ParseRenderInfo() values = %v, want %v", v1.Values, wantValues)
+	}
+
+	// Migrated renderinfos have to survive a round trip unchanged
+	b, err := json.Marshal(v1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	v2, err := ParseRenderInfo(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(v1, v2) {
+		t.Errorf("ParseRenderInfo() round trip = %+v, want %+v", v2, v1)
+	}
+
+	if _, err := ParseRenderInfo([]byte(`{"version": 3}`)); err == nil {
+		t.Errorf("ParseRenderInfo() expected error for unsupported version")
+	}
+}
+
+func Test_parseRenderInfoV1Date(t *testing.T) {
+	tests := []struct {
+		date    string
+		want    time.Time
+		wantErr bool
+	}{
+		{date: "17 Oct 20 17:42 UTC", want: time.Date(2020, 10, 17, 17, 42, 0, 0, time.UTC)},
+		{date: "17 Oct 20 17:42 +0200", want: time.Date(2020, 10, 17, 15, 42, 0, 0, time.UTC)},
+		{date: ""},
+		{date: "2020-10-17", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.date, func(t *testing.T) {
+			got, err := parseRenderInfoV1Date(tt.date)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseRenderInfoV1Date() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("parseRenderInfoV1Date() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMigrateRenderInfoTree(t *testing.T) {
+	root, err := ioutil.TempDir("", "kable-renderinfo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	old := filepath.Join(root, "a", ConceptRenderFileName)
+	if err := os.MkdirAll(filepath.Dir(old), os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(old, []byte(renderInfoV1Fixture), 0644); err != nil {
+		t.Fatal(err)
+	}
+	current, err := NewRenderV2(&RenderValues{"instanceName": StringValueType("b")}, nil, "b", RenderTarget{Type: YamlTargetType})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteRenderInfoFile(filepath.Join(root, ConceptRenderFileName), current); err != nil {
+		t.Fatal(err)
+	}
+
+	migrated, err := MigrateRenderInfoTree(root, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(migrated) != 1 || migrated[0] != old {
+		t.Errorf("MigrateRenderInfoTree() = %v, want [%s]", migrated, old)
+	}
+	if _, err := MigrateRenderInfoTree(root, false); err != nil {
+		t.Fatal(err)
+	}
+	if migrated, _ := MigrateRenderInfoTree(root, true); len(migrated) != 0 {
+		t.Errorf("MigrateRenderInfoTree() left outdated files %v", migrated)
+	}
+
+	// A date that can't be carried over fails the migration, instead of being dropped
+	invalid := strings.Replace(renderInfoV1Fixture, "17 Oct 20 17:42 UTC", "yesterday", 1)
+	if err := ioutil.WriteFile(old, []byte(invalid), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := MigrateRenderInfoTree(root, false); err == nil || !strings.Contains(err.Error(), old) {
+		t.Errorf("MigrateRenderInfoTree() error = %v, want error naming '%s'", err, old)
+	}
+	if b, err := ioutil.ReadFile(old); err != nil || string(b) != invalid {
+		t.Errorf("MigrateRenderInfoTree() modified a file it failed to migrate")
+	}
+}
@@ -1,12 +1,18 @@
 package concepts
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"fmt"
+	"io"
 	"io/ioutil"
+	"math"
 	"os"
 	"path/filepath"
 	"regexp"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/redradrat/kable/pkg/repositories"
@@ -32,6 +38,8 @@ type File struct {
 type Render struct {
 	Info  *File
 	Files []File
+	// Migrations reports the values, that have been migrated according to the concept's migrations
+	Migrations *MigrationReport
 }
 
 func (f File) String() string {
@@ -84,12 +92,83 @@ func (r Render) WriteInfo(baseDir string) error {
 	return nil
 }
 
-// RenderInfoV1 defines model for RenderInfoV1.
+// RenderInfo parses the renderinfo of this render
+func (r Render) RenderInfo() (*RenderInfoV2, error) {
+	if r.Info == nil {
+		return nil, os.ErrNotExist
+	}
+	return ParseRenderInfo(r.Info.content)
+}
+
+// FileDigests returns the sha256 digest of each rendered file by path
+func (r Render) FileDigests() map[string]string {
+	out := map[string]string{}
+	for _, f := range r.Files {
+		sum := sha256.Sum256(f.content)
+		out[f.path] = hex.EncodeToString(sum[:])
+	}
+	return out
+}
+
+// ReadRender reads a previously written render from dir. Dot-files and -directories, as well as nested renders, are
+// ignored.
+func ReadRender(dir string) (*Render, error) {
+	render := Render{}
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if path == dir {
+			return nil
+		}
+		if strings.HasPrefix(info.Name(), ".") {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if info.IsDir() {
+			if _, err := os.Stat(filepath.Join(path, ConceptRenderFileName)); err == nil {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		content, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		file := File{path: filepath.ToSlash(rel), content: content}
+		if rel == ConceptRenderFileName {
+			render.Info = &file
+		} else {
+			render.Files = append(render.Files, file)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if render.Info == nil {
+		return nil, fmt.Errorf("no %s found in '%s'", ConceptRenderFileName, dir)
+	}
+	return &render, nil
+}
+
+// RenderInfoV1 defines model for RenderInfoV1. It is superseded by RenderInfoV2, and only read for migration.
 type RenderInfoV1 struct {
-	Version int            `json:"version"`
-	Meta    RenderMeta     `json:"meta"`
-	Origin  *ConceptOrigin `json:"origin,omitempty"`
-	Values  *RenderValues  `json:"values,omitempty"`
+	Version      int            `json:"version"`
+	Meta         RenderMeta     `json:"meta"`
+	Origin       *ConceptOrigin `json:"origin,omitempty"`
+	Values       *RenderValues  `json:"values,omitempty"`
+	Environments []string       `json:"environments,omitempty"`
+	Layers       ValueLayers    `json:"layers,omitempty"`
 }
 
 func ParseRenderInfoV1FromFile(path string) (*RenderInfoV1, error) {
@@ -116,22 +195,33 @@ type ValueType interface {
 
 type RenderValues map[string]ValueType
 
-func (rv RenderValues) UnmarshalJSON(bytes []byte) error {
+func (rv *RenderValues) UnmarshalJSON(bytes []byte) error {
 	inter := map[string]interface{}{}
 	if err := json.Unmarshal(bytes, &inter); err != nil {
 		return err
 	}
 
+	if *rv == nil {
+		*rv = RenderValues{}
+	}
 	for k, v := range inter {
 		switch assertedValue := v.(type) {
 		case string:
-			rv[k] = StringValueType(assertedValue)
+			(*rv)[k] = StringValueType(assertedValue)
 		case map[string]interface{}:
-			rv[k] = MapValueType(assertedValue)
-		case int:
-			rv[k] = IntValueType(assertedValue)
+			(*rv)[k] = MapValueType(assertedValue)
+		case float64:
+			// JSON numbers always decode as float64, only integral numbers are supported
+			if assertedValue != math.Trunc(assertedValue) {
+				return fmt.Errorf("%w: non-integer number for value '%s'", errors.ValueTypeNotSupported, k)
+			}
+			(*rv)[k] = IntValueType(assertedValue)
 		case bool:
-			rv[k] = BoolValueType(assertedValue)
+			(*rv)[k] = BoolValueType(assertedValue)
+		case nil:
+			continue
+		default:
+			return fmt.Errorf("%w: value '%s'", errors.ValueTypeNotSupported, k)
 		}
 	}
 	return nil
@@ -196,6 +286,18 @@ type RenderOpts struct {
 	Local           bool
 	WriteRenderInfo bool
 	Single          bool
+	NoCache         bool
+	// Environments and Layers are recorded in the renderinfo, if values were resolved from ValuesOverlays
+	Environments []string
+	Layers       ValueLayers
+	// HookOutput receives the output of the concept's hooks. If nil, the output is discarded.
+	HookOutput io.Writer
+	// NoValueReferences rejects values containing references, instead of resolving them. Resolvers read the
+	// environment and files, and execute commands, so they must not be available to untrusted callers.
+	NoValueReferences bool
+	// NoHooks rejects concepts declaring hooks, instead of running them. Hooks execute arbitrary commands, so they must
+	// not be available to untrusted callers either.
+	NoHooks bool
 }
 
 func NewRenderV1(avs *RenderValues, origin *ConceptOrigin) (*RenderInfoV1, error) {
@@ -240,9 +342,13 @@ func renderConcept(id string, avs *RenderValues, ttype TargetType, opts RenderOp
 		path = filepath.Join(repopath, ci.Concept())
 
 		// Get the origin of the the concept
-		origin = &ConceptOrigin{
-			Repository: r.URL,
-			Ref:        r.GitRef,
+		origin, err = conceptOrigin(r)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		if id, err = filepath.Abs(path); err != nil {
+			return nil, err
 		}
 	}
 
@@ -260,16 +366,71 @@ func renderConcept(id string, avs *RenderValues, ttype TargetType, opts RenderOp
 	if err != nil {
 		return nil, err
 	}
+	if opts.NoHooks && !cpt.Hooks.IsEmpty() {
+		return nil, fmt.Errorf("%w: concept '%s' declares hooks", errors.HooksDisabledError, id)
+	}
+
+	// Migrate values of existing renders to the current inputs of the concept
+	avs, migrations := cpt.Migrations.Apply(avs)
 
-	render, err := target.Render(path, avs, cpt.Type, opts.Single)
+	// Resolve any value references, while the renderinfo keeps the unresolved values
+	refs := ValueReferences(avs)
+	if opts.NoValueReferences && len(refs) != 0 {
+		return nil, fmt.Errorf("%w: '%s'", errors.ValueReferencesDisabledError, strings.Join(refs, "', '"))
+	}
+	resolved, err := ResolveValues(avs)
 	if err != nil {
 		return nil, err
 	}
 
-	cr, err := NewRenderV1(avs, origin)
+	// Assets generated by a pre-render hook have to be evaluated, before another render of the concept replaces them
+	if !cpt.Hooks.IsEmpty() {
+		unlock, err := lockConceptDir(path)
+		if err != nil {
+			return nil, err
+		}
+		defer unlock()
+	}
+	if cpt.Hooks.PreRender != nil {
+		if err := cpt.Hooks.PreRender.Run(path, resolved, nil, opts.HookOutput); err != nil {
+			return nil, err
+		}
+	}
+
+	// Serve unchanged renders from the cache. Caching is best effort, so cache errors never fail a render. Hooks may
+	// have arbitrary side effects, so concepts declaring hooks are never cached. Neither are renders with value
+	// references, as their resolved values may be secrets, that must not be written to the cache.
+	var render *Render
+	var cacheKey string
+	if !opts.NoCache && cpt.Hooks.IsEmpty() && len(refs) == 0 {
+		if cacheKey, err = renderCacheKey(path, cpt.Type, resolved, ttype, opts.Single); err == nil {
+			render = loadCachedRender(cacheKey)
+		}
+	}
+	if render == nil {
+		render, err = target.Render(path, resolved, cpt.Type, opts.Single)
+		if err != nil {
+			return nil, err
+		}
+		if cacheKey != "" {
+			_ = storeCachedRender(cacheKey, render)
+		}
+	}
+
+	if cpt.Hooks.PostRender != nil {
+		if err := runPostRenderHook(*cpt.Hooks.PostRender, path, resolved, render, opts.HookOutput); err != nil {
+			return nil, err
+		}
+	}
+
+	cr, err := NewRenderV2(avs, origin, id, RenderTarget{Type: ttype, Single: opts.Single, Local: opts.Local})
 	if err != nil {
 		return nil, err
 	}
+	cr.Environments = opts.Environments
+	cr.Layers = opts.Layers
+	cr.Migrations = migrations
+	cr.Files = render.FileDigests()
 
 	appFile, err := json.MarshalIndent(cr, "", "	")
 	if err != nil {
@@ -280,6 +441,7 @@ func renderConcept(id string, avs *RenderValues, ttype TargetType, opts RenderOp
 		path:    ConceptRenderFileName,
 		content: appFile,
 	}
+	render.Migrations = migrations
 
 	return render, nil
 }
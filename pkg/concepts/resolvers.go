@@ -0,0 +1,310 @@
+package concepts
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"sigs.k8s.io/yaml"
+
+	"github.com/redradrat/kable/pkg/errors"
+)
+
+const (
+	ValueReferencePrefix = "ref+"
+	EnvResolverScheme    = "env"
+	FileResolverScheme   = "file"
+	ExecResolverScheme   = "exec"
+)
+
+// ValueReference is a parsed reference string, e.g. 'ref+file://path#.json.path'
+type ValueReference struct {
+	Scheme   string
+	Path     string
+	Fragment string
+}
+
+func (vr ValueReference) String() string {
+	out := ValueReferencePrefix + vr.Scheme + "://" + vr.Path
+	if vr.Fragment != "" {
+		out = out + "#" + vr.Fragment
+	}
+	return out
+}
+
+// IsValueReference returns whether the given string is meant to be resolved at render time
+func IsValueReference(s string) bool {
+	return strings.HasPrefix(s, ValueReferencePrefix)
+}
+
+func ParseValueReference(s string) (*ValueReference, error) {
+	if !IsValueReference(s) {
+		return nil, errors.InvalidValueReferenceError
+	}
+	parts := strings.SplitN(strings.TrimPrefix(s, ValueReferencePrefix), "://", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return nil, errors.InvalidValueReferenceError
+	}
+	ref := &ValueReference{Scheme: parts[0], Path: parts[1]}
+	if i := strings.Index(ref.Path, "#"); i >= 0 {
+		ref.Fragment = ref.Path[i+1:]
+		ref.Path = ref.Path[:i]
+	}
+	return ref, nil
+}
+
+// ValueResolver is the interface for everything that can turn a ValueReference into an actual value
+type ValueResolver interface {
+	Resolve(ref ValueReference) (string, error)
+}
+
+var (
+	resolversMu    sync.RWMutex
+	valueResolvers = map[string]ValueResolver{
+		EnvResolverScheme:  EnvResolver{},
+		FileResolverScheme: FileResolver{},
+		ExecResolverScheme: ExecResolver{},
+	}
+)
+
+// RegisterValueResolver makes the given resolver available for references of the given scheme. An existing resolver
+// for the same scheme is replaced.
+func RegisterValueResolver(scheme string, resolver ValueResolver) {
+	resolversMu.Lock()
+	defer resolversMu.Unlock()
+	valueResolvers[scheme] = resolver
+}
+
+func getValueResolver(scheme string) (ValueResolver, error) {
+	resolversMu.RLock()
+	defer resolversMu.RUnlock()
+	resolver, ok := valueResolvers[scheme]
+	if !ok {
+		return nil, fmt.Errorf("%w: '%s'", errors.UnknownValueResolverError, scheme)
+	}
+	return resolver, nil
+}
+
+// ResolveValueReference resolves a single reference string with the registered resolvers
+func ResolveValueReference(s string) (string, error) {
+	ref, err := ParseValueReference(s)
+	if err != nil {
+		return "", err
+	}
+	resolver, err := getValueResolver(ref.Scheme)
+	if err != nil {
+		return "", err
+	}
+	out, err := resolver.Resolve(*ref)
+	if err != nil {
+		return "", fmt.Errorf("unable to resolve '%s': %w", s, err)
+	}
+	return out, nil
+}
+
+// ValueReferences returns all reference strings contained in the given values, including those nested in maps
+func ValueReferences(avs *RenderValues) []string {
+	if avs == nil {
+		return nil
+	}
+	var refs []string
+	for _, v := range *avs {
+		switch typedValue := v.(type) {
+		case StringValueType:
+			if IsValueReference(typedValue.String()) {
+				refs = append(refs, typedValue.String())
+			}
+		case MapValueType:
+			refs = append(refs, mapValueReferences(typedValue)...)
+		}
+	}
+	sort.Strings(refs)
+	return refs
+}
+
+func mapValueReferences(in map[string]interface{}) []string {
+	var refs []string
+	for _, v := range in {
+		refs = append(refs, nestedValueReferences(v)...)
+	}
+	return refs
+}
+
+// nestedValueReferences returns all reference strings within a value of a map, including those in nested maps and
+// arrays
+func nestedValueReferences(v interface{}) []string {
+	switch typedValue := v.(type) {
+	case string:
+		if IsValueReference(typedValue) {
+			return []string{typedValue}
+		}
+	case map[string]interface{}:
+		return mapValueReferences(typedValue)
+	case []interface{}:
+		var refs []string
+		for _, elem := range typedValue {
+			refs = append(refs, nestedValueReferences(elem)...)
+		}
+		return refs
+	}
+	return nil
+}
+
+// ResolveValues returns a copy of the given values, with all references replaced by their resolved values. The given
+// values are left untouched, so they can still be stored unresolved.
+func ResolveValues(avs *RenderValues) (*RenderValues, error) {
+	if avs == nil {
+		return nil, nil
+	}
+	out := RenderValues{}
+	for k, v := range *avs {
+		switch typedValue := v.(type) {
+		case StringValueType:
+			if !IsValueReference(typedValue.String()) {
+				out[k] = typedValue
+				continue
+			}
+			resolved, err := ResolveValueReference(typedValue.String())
+			if err != nil {
+				return nil, err
+			}
+			out[k] = StringValueType(resolved)
+		case MapValueType:
+			resolved, err := resolveMapReferences(typedValue)
+			if err != nil {
+				return nil, err
+			}
+			out[k] = MapValueType(resolved)
+		default:
+			out[k] = v
+		}
+	}
+	return &out, nil
+}
+
+func resolveMapReferences(in map[string]interface{}) (map[string]interface{}, error) {
+	out := make(map[string]interface{}, len(in))
+	for k, v := range in {
+		resolved, err := resolveNestedReferences(v)
+		if err != nil {
+			return nil, err
+		}
+		out[k] = resolved
+	}
+	return out, nil
+}
+
+// resolveNestedReferences resolves all references within a value of a map, including those in nested maps and arrays
+func resolveNestedReferences(v interface{}) (interface{}, error) {
+	switch typedValue := v.(type) {
+	case string:
+		if !IsValueReference(typedValue) {
+			return typedValue, nil
+		}
+		return ResolveValueReference(typedValue)
+	case map[string]interface{}:
+		return resolveMapReferences(typedValue)
+	case []interface{}:
+		out := make([]interface{}, len(typedValue))
+		for i, elem := range typedValue {
+			resolved, err := resolveNestedReferences(elem)
+			if err != nil {
+				return nil, err
+			}
+			out[i] = resolved
+		}
+		return out, nil
+	default:
+		return v, nil
+	}
+}
+
+// EnvResolver resolves 'ref+env://NAME' to the content of the environment variable NAME
+type EnvResolver struct{}
+
+func (r EnvResolver) Resolve(ref ValueReference) (string, error) {
+	val, ok := os.LookupEnv(ref.Path)
+	if !ok {
+		return "", fmt.Errorf("environment variable '%s' is not set", ref.Path)
+	}
+	return val, nil
+}
+
+// FileResolver resolves 'ref+file://path' to the content of the file at path. If a fragment like '#.json.path' is
+// given, the file is parsed as JSON (or YAML) and the value at the given path is returned.
+type FileResolver struct{}
+
+func (r FileResolver) Resolve(ref ValueReference) (string, error) {
+	content, err := ioutil.ReadFile(ref.Path)
+	if err != nil {
+		return "", err
+	}
+	if ref.Fragment == "" {
+		return strings.TrimSpace(string(content)), nil
+	}
+
+	jsonContent, err := yaml.YAMLToJSON(content)
+	if err != nil {
+		return "", err
+	}
+	var doc interface{}
+	if err := json.Unmarshal(jsonContent, &doc); err != nil {
+		return "", err
+	}
+	return lookupDocumentPath(doc, ref.Fragment)
+}
+
+func lookupDocumentPath(doc interface{}, path string) (string, error) {
+	current := doc
+	for _, element := range strings.Split(strings.TrimPrefix(path, "."), ".") {
+		if element == "" {
+			continue
+		}
+		switch typedCurrent := current.(type) {
+		case map[string]interface{}:
+			next, ok := typedCurrent[element]
+			if !ok {
+				return "", fmt.Errorf("path '%s' does not exist in document", path)
+			}
+			current = next
+		case []interface{}:
+			idx, err := strconv.Atoi(element)
+			if err != nil || idx < 0 || idx >= len(typedCurrent) {
+				return "", fmt.Errorf("path '%s' does not exist in document", path)
+			}
+			current = typedCurrent[idx]
+		default:
+			return "", fmt.Errorf("path '%s' does not exist in document", path)
+		}
+	}
+
+	if s, ok := current.(string); ok {
+		return s, nil
+	}
+	out, err := json.Marshal(current)
+	if err != nil {
+		return "", err
+	}
+	return string(out), nil
+}
+
+// ExecResolver resolves 'ref+exec://command' to the trimmed stdout of the given shell command
+type ExecResolver struct{}
+
+func (r ExecResolver) Resolve(ref ValueReference) (string, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command("sh", "-c", ref.Path)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("command failed: %v: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return strings.TrimSpace(stdout.String()), nil
+}
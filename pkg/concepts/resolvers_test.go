@@ -0,0 +1,111 @@
+package concepts
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestParseValueReference(t *testing.T) {
+	tests := []struct {
+		name    string
+		in      string
+		want    *ValueReference
+		wantErr bool
+	}{
+		{name: "env", in: "ref+env://DB_HOST", want: &ValueReference{Scheme: "env", Path: "DB_HOST"}},
+		{name: "file with fragment", in: "ref+file://values.json#.db.host", want: &ValueReference{Scheme: "file", Path: "values.json", Fragment: ".db.host"}},
+		{name: "exec", in: "ref+exec://echo test", want: &ValueReference{Scheme: "exec", Path: "echo test"}},
+		{name: "no reference", in: "DB_HOST", wantErr: true},
+		{name: "no scheme", in: "ref+://DB_HOST", wantErr: true},
+		{name: "no path", in: "ref+env://", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseValueReference(tt.in)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseValueReference() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseValueReference() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolveValues(t *testing.T) {
+	dir, err := ioutil.TempDir("", "kable-resolvers")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	valuesFile := filepath.Join(dir, "values.json")
+	if err := ioutil.WriteFile(valuesFile, []byte(`{"db": {"hosts": ["db-0", "db-1"], "port": 5432}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Setenv("KABLE_TEST_DB_HOST", "db.example.com"); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Unsetenv("KABLE_TEST_DB_HOST")
+
+	tests := []struct {
+		name    string
+		in      RenderValues
+		want    RenderValues
+		wantErr bool
+	}{
+		{name: "plain", in: RenderValues{"a": StringValueType("b"), "i": IntValueType(1)}, want: RenderValues{"a": StringValueType("b"), "i": IntValueType(1)}},
+		{name: "env", in: RenderValues{"host": StringValueType("ref+env://KABLE_TEST_DB_HOST")}, want: RenderValues{"host": StringValueType("db.example.com")}},
+		{name: "file", in: RenderValues{"host": StringValueType("ref+file://" + valuesFile + "#.db.hosts.1")}, want: RenderValues{"host": StringValueType("db-1")}},
+		{name: "file number", in: RenderValues{"port": StringValueType("ref+file://" + valuesFile + "#.db.port")}, want: RenderValues{"port": StringValueType("5432")}},
+		{name: "exec", in: RenderValues{"bucket": StringValueType("ref+exec://echo my-bucket")}, want: RenderValues{"bucket": StringValueType("my-bucket")}},
+		{name: "map", in: RenderValues{"db": MapValueType{"host": "ref+env://KABLE_TEST_DB_HOST"}}, want: RenderValues{"db": MapValueType{"host": "db.example.com"}}},
+		{name: "array in map", in: RenderValues{"db": MapValueType{"hosts": []interface{}{"db-0", "ref+env://KABLE_TEST_DB_HOST"}}}, want: RenderValues{"db": MapValueType{"hosts": []interface{}{"db-0", "db.example.com"}}}},
+		{name: "map in array", in: RenderValues{"db": MapValueType{"replicas": []interface{}{map[string]interface{}{"host": "ref+env://KABLE_TEST_DB_HOST"}, 1}}}, want: RenderValues{"db": MapValueType{"replicas": []interface{}{map[string]interface{}{"host": "db.example.com"}, 1}}}},
+		{name: "unset env in array", in: RenderValues{"db": MapValueType{"hosts": []interface{}{"ref+env://KABLE_TEST_UNSET"}}}, wantErr: true},
+		{name: "unset env", in: RenderValues{"host": StringValueType("ref+env://KABLE_TEST_UNSET")}, wantErr: true},
+		{name: "unknown scheme", in: RenderValues{"host": StringValueType("ref+vault://secret/db")}, wantErr: true},
+		{name: "missing path", in: RenderValues{"host": StringValueType("ref+file://" + valuesFile + "#.db.user")}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ResolveValues(&tt.in)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ResolveValues() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+			if !reflect.DeepEqual(*got, tt.want) {
+				t.Errorf("ResolveValues() got = %v, want %v", *got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValueReferences(t *testing.T) {
+	tests := []struct {
+		name string
+		in   *RenderValues
+		want []string
+	}{
+		{name: "nil"},
+		{name: "plain", in: &RenderValues{"a": StringValueType("b"), "i": IntValueType(1)}},
+		{name: "string", in: &RenderValues{"a": StringValueType("ref+env://A")}, want: []string{"ref+env://A"}},
+		{name: "map", in: &RenderValues{"m": MapValueType{"a": map[string]interface{}{"b": "ref+env://B"}}}, want: []string{"ref+env://B"}},
+		{name: "array in map", in: &RenderValues{"m": MapValueType{"a": []interface{}{"x", "ref+exec://c"}}}, want: []string{"ref+exec://c"}},
+		{name: "nested arrays", in: &RenderValues{"m": MapValueType{"a": []interface{}{[]interface{}{map[string]interface{}{"d": "ref+file://d"}}}}}, want: []string{"ref+file://d"}},
+		{name: "sorted", in: &RenderValues{"b": StringValueType("ref+env://B"), "a": MapValueType{"x": []interface{}{"ref+env://A"}}}, want: []string{"ref+env://A", "ref+env://B"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ValueReferences(tt.in); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ValueReferences() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,137 @@
+package concepts
+
+import (
+	"sort"
+	"strings"
+)
+
+// Weights of the places a free text term can match in, the name weighing the most
+const (
+	nameExactScore   = 10
+	nameScore        = 5
+	tagScore         = 4
+	pathScore        = 3
+	descriptionScore = 2
+	maintainerScore  = 1
+)
+
+// SearchQuery selects and ranks concepts. Empty fields match all concepts.
+type SearchQuery struct {
+	// Text are whitespace separated terms, that all have to occur in the name, path, tags, description or
+	// maintainer of a concept
+	Text string
+	// Tags all have to be set on a concept
+	Tags []string
+	// Maintainer has to occur in the name or email of the maintainer
+	Maintainer string
+	Repository string
+	Type       ConceptType
+}
+
+// SearchResult is a concept matching a SearchQuery, and its score. Higher scores rank first.
+type SearchResult struct {
+	ID      ConceptIdentifier
+	Concept *Concept
+	Score   int
+}
+
+// IsEmpty returns whether the query matches all concepts
+func (q SearchQuery) IsEmpty() bool {
+	return strings.TrimSpace(q.Text) == "" && len(q.Tags) == 0 && q.Maintainer == "" && q.Repository == "" && q.Type == ""
+}
+
+// Match returns whether the concept matches the query, and its score. All matching is case-insensitive.
+func (q SearchQuery) Match(id ConceptIdentifier, c Concept) (int, bool) {
+	if q.Repository != "" && id.Repo() != q.Repository {
+		return 0, false
+	}
+	if q.Type != "" && c.Type != q.Type {
+		return 0, false
+	}
+	if q.Maintainer != "" && !containsFold(c.Meta.Maintainer.String(), q.Maintainer) {
+		return 0, false
+	}
+	for _, tag := range q.Tags {
+		if !hasTagFold(c.Meta.Tags, tag) {
+			return 0, false
+		}
+	}
+
+	score := 0
+	for _, term := range strings.Fields(q.Text) {
+		termScore := 0
+		switch {
+		case strings.EqualFold(c.Meta.Name, term):
+			termScore += nameExactScore
+		case containsFold(c.Meta.Name, term):
+			termScore += nameScore
+		}
+		if hasTagFold(c.Meta.Tags, term) {
+			termScore += tagScore
+		}
+		if containsFold(id.Concept(), term) {
+			termScore += pathScore
+		}
+		if containsFold(c.Meta.Description, term) {
+			termScore += descriptionScore
+		}
+		if containsFold(c.Meta.Maintainer.String(), term) {
+			termScore += maintainerScore
+		}
+		if termScore == 0 {
+			return 0, false
+		}
+		score += termScore
+	}
+	return score, true
+}
+
+// Search returns the given concepts matching the query, ranked by score and identifier.
+func (q SearchQuery) Search(cpts map[ConceptIdentifier]*Concept) []SearchResult {
+	var results []SearchResult
+	for id, c := range cpts {
+		if score, ok := q.Match(id, *c); ok {
+			results = append(results, SearchResult{ID: id, Concept: c, Score: score})
+		}
+	}
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return results[i].ID < results[j].ID
+	})
+	return results
+}
+
+// SearchConcepts searches all concepts of all configured repositories
+func SearchConcepts(q SearchQuery) ([]SearchResult, error) {
+	cis, err := ListConcepts()
+	if err != nil {
+		return nil, err
+	}
+	cpts := map[ConceptIdentifier]*Concept{}
+	for _, ci := range cis {
+		if q.Repository != "" && ci.Repo() != q.Repository {
+			continue
+		}
+		c, err := GetRepoConcept(ci)
+		if err != nil {
+			return nil, err
+		}
+		cpts[ci] = c
+	}
+	return q.Search(cpts), nil
+}
+
+func containsFold(s, substr string) bool {
+	return strings.Contains(strings.ToLower(s), strings.ToLower(substr))
+}
+
+func hasTagFold(tags []string, tag string) bool {
+	for _, t := range tags {
+		if strings.EqualFold(t, tag) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,47 @@
+package concepts
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSearchQuery_Search(t *testing.T) {
+	cpts := map[ConceptIdentifier]*Concept{
+		"apps/grafana@demo": {Type: ConceptJsonnetType, Meta: ConceptMeta{
+			Name: "grafana", Description: "Dashboards for prometheus", Tags: Tags{"monitoring", "ui"},
+			Maintainer: MaintainerInfo{Name: "Jane", Email: "jane@kab.le"},
+		}},
+		"apps/prometheus@demo": {Type: ConceptJsonnetType, Meta: ConceptMeta{
+			Name: "prometheus", Description: "Metrics and alerting", Tags: Tags{"monitoring"},
+			Maintainer: MaintainerInfo{Name: "Joe"},
+		}},
+		"infra/dns@other": {Type: "helm", Meta: ConceptMeta{
+			Name: "dns", Description: "External DNS", Maintainer: MaintainerInfo{Name: "Jane"},
+		}},
+	}
+	tests := []struct {
+		name  string
+		query SearchQuery
+		want  []ConceptIdentifier
+	}{
+		{name: "empty", query: SearchQuery{}, want: []ConceptIdentifier{"apps/grafana@demo", "apps/prometheus@demo", "infra/dns@other"}},
+		{name: "exact name ranks first", query: SearchQuery{Text: "Prometheus"}, want: []ConceptIdentifier{"apps/prometheus@demo", "apps/grafana@demo"}},
+		{name: "all terms", query: SearchQuery{Text: "grafana dashboards"}, want: []ConceptIdentifier{"apps/grafana@demo"}},
+		{name: "no match", query: SearchQuery{Text: "grafana dns"}},
+		{name: "tags", query: SearchQuery{Tags: []string{"Monitoring", "ui"}}, want: []ConceptIdentifier{"apps/grafana@demo"}},
+		{name: "maintainer", query: SearchQuery{Maintainer: "jane"}, want: []ConceptIdentifier{"apps/grafana@demo", "infra/dns@other"}},
+		{name: "repository", query: SearchQuery{Repository: "other"}, want: []ConceptIdentifier{"infra/dns@other"}},
+		{name: "type", query: SearchQuery{Type: ConceptJsonnetType, Text: "monitoring"}, want: []ConceptIdentifier{"apps/grafana@demo", "apps/prometheus@demo"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []ConceptIdentifier
+			for _, res := range tt.query.Search(cpts) {
+				got = append(got, res.ID)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Search() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,200 @@
+package concepts
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+
+	"github.com/redradrat/kable/pkg/errors"
+	"github.com/redradrat/kable/pkg/repositories"
+)
+
+// ConceptVerification is the outcome of verifying a single concept of a repository
+type ConceptVerification struct {
+	Concept string   `json:"concept"`
+	Errors  []string `json:"errors,omitempty"`
+}
+
+func (cv ConceptVerification) Passed() bool {
+	return len(cv.Errors) == 0
+}
+
+// VerifyReport collects the verification results of all concepts of a repository
+type VerifyReport struct {
+	Results []ConceptVerification `json:"results"`
+}
+
+// Failed returns the results of all concepts, that did not pass verification
+func (vr VerifyReport) Failed() []ConceptVerification {
+	var failed []ConceptVerification
+	for _, res := range vr.Results {
+		if !res.Passed() {
+			failed = append(failed, res)
+		}
+	}
+	return failed
+}
+
+// VerifyRepository checks every concept listed by the index of the repository directory at path: its concept.json
+// has to parse, its type and input types have to be supported, its dependencies have to be vendored, and it has to
+// render with the example values of its inputs.
+func VerifyRepository(path string) (*VerifyReport, error) {
+	idx, err := repositories.ReadRepoIndex(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read repository index: %w", err)
+	}
+	paths, err := RepoConcepts(path, *idx)
+	if err != nil {
+		return nil, err
+	}
+
+	report := VerifyReport{}
+	for _, p := range paths {
+		report.Results = append(report.Results, ConceptVerification{
+			Concept: p,
+			Errors:  verifyConcept(filepath.Join(path, filepath.FromSlash(p))),
+		})
+	}
+	return &report, nil
+}
+
+// verifyConcept returns the problems found with the concept at path. Rendering is only attempted, if all static checks
+// passed.
+func verifyConcept(path string) []string {
+	cpt, err := GetConcept(path)
+	if err != nil {
+		return []string{fmt.Sprintf("concept: %s", err)}
+	}
+
+	var problems []string
+	if !cpt.Type.IsSupported() {
+		problems = append(problems, fmt.Sprintf("type: %s: '%s'", errors.ConceptTypeUnsupportedError, cpt.Type))
+	}
+	if err := cpt.Inputs.Validate(); err != nil {
+		problems = append(problems, fmt.Sprintf("inputs: %s", err))
+	}
+	if err := checkVendored(path, cpt.Type); err != nil {
+		problems = append(problems, fmt.Sprintf("vendor: %s", err))
+	}
+	if len(problems) != 0 {
+		return problems
+	}
+
+	avs, err := cpt.Inputs.ExampleValues()
+	if err != nil {
+		return []string{fmt.Sprintf("inputs: %s", err)}
+	}
+	if _, err := RenderConcept(path, avs, YamlTargetType, RenderOpts{Local: true, NoCache: true}); err != nil {
+		return []string{fmt.Sprintf("render: %s", err)}
+	}
+	return nil
+}
+
+// checkVendored makes sure, that the dependencies declared in the jsonnetfile.json of jsonnet concepts are installed
+func checkVendored(path string, ct ConceptType) error {
+	if ct != ConceptJsonnetType {
+		return nil
+	}
+	content, err := ioutil.ReadFile(filepath.Join(path, ConceptJsonnetfile))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	jf := struct {
+		Dependencies []json.RawMessage `json:"dependencies"`
+	}{}
+	if err := json.Unmarshal(content, &jf); err != nil {
+		return fmt.Errorf("unable to parse '%s': %w", ConceptJsonnetfile, err)
+	}
+	if len(jf.Dependencies) == 0 {
+		return nil
+	}
+	info, err := os.Stat(filepath.Join(path, ConceptVendorDir))
+	if os.IsNotExist(err) || (err == nil && !info.IsDir()) {
+		return fmt.Errorf("%w: '%s' is missing (run 'jb install')", errors.VendorDirMissingError, ConceptVendorDir)
+	}
+	return err
+}
+
+// Validate checks, that all inputs have a supported type, and that selections offer options
+func (ci ConceptInputs) Validate() error {
+	for _, inputs := range []map[string]InputType{ci.Mandatory, ci.Optional} {
+		for _, id := range sortedInputIDs(inputs) {
+			input := inputs[id]
+			if !input.Type.IsValid() {
+				return fmt.Errorf("%w: '%s' of input '%s'", errors.InputTypeUnsupportedError, input.Type, id)
+			}
+			if input.Type == ConceptSelectionInputType && len(input.Options) == 0 {
+				return fmt.Errorf("selection input '%s' has no options", id)
+			}
+		}
+	}
+	return nil
+}
+
+// ExampleValues returns values for all mandatory inputs, taken from their examples. Inputs without an example get the
+// first option for selections, the input ID for strings, and the zero value otherwise.
+func (ci ConceptInputs) ExampleValues() (*RenderValues, error) {
+	avs := RenderValues{}
+	for id, input := range ci.Mandatory {
+		val, err := input.exampleValue(id)
+		if err != nil {
+			return nil, fmt.Errorf("invalid example of input '%s': %w", id, err)
+		}
+		avs[id] = val
+	}
+	return &avs, nil
+}
+
+func (it InputType) exampleValue(id string) (ValueType, error) {
+	switch it.Type {
+	case ConceptStringInputType:
+		if it.Example == "" {
+			return StringValueType(id), nil
+		}
+		return StringValueType(it.Example), nil
+	case ConceptSelectionInputType:
+		if it.Example == "" {
+			if len(it.Options) == 0 {
+				return nil, fmt.Errorf("selection input '%s' has no options", id)
+			}
+			return StringValueType(it.Options[0]), nil
+		}
+		return StringValueType(it.Example), nil
+	case ConceptIntInputType:
+		if it.Example == "" {
+			return IntValueType(0), nil
+		}
+		i, err := strconv.Atoi(it.Example)
+		return IntValueType(i), err
+	case ConceptBoolInputType:
+		if it.Example == "" {
+			return BoolValueType(false), nil
+		}
+		b, err := strconv.ParseBool(it.Example)
+		return BoolValueType(b), err
+	case ConceptMapInputType:
+		m := MapValueType{}
+		if it.Example == "" {
+			return m, nil
+		}
+		return m, json.Unmarshal([]byte(it.Example), &m)
+	default:
+		return nil, fmt.Errorf("%w: '%s'", errors.InputTypeUnsupportedError, it.Type)
+	}
+}
+
+func sortedInputIDs(inputs map[string]InputType) []string {
+	var ids []string
+	for id := range inputs {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
@@ -0,0 +1,92 @@
+package concepts
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/redradrat/kable/pkg/repositories"
+)
+
+func TestVerifyRepository(t *testing.T) {
+	defer withTempRenderCache(t)()
+	root, err := ioutil.TempDir("", "kable-verify")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	main := `{ cm: { apiVersion: "v1", kind: "ConfigMap", metadata: { name: std.extVar("name") } } }`
+	concept := func(inputType string) string {
+		return `{"apiVersion": 1, "type": "jsonnet", "metadata": {"name": "c"}, "inputs": {"mandatory": {"name": {"type": "` + inputType + `", "example": "demo"}}}}`
+	}
+	files := map[string]string{
+		repositories.RepoIndexFileName:   `{"version": 1, "concepts": ["good", "missing", "badinput", "broken", "novendor"]}`,
+		"good/" + ConceptFileName:        concept("string"),
+		"good/" + ConceptMainJsonnet:     main,
+		"good/" + ConceptJsonnetfile:     `{"version": 1, "dependencies": []}`,
+		"badinput/" + ConceptFileName:    concept("float"),
+		"badinput/" + ConceptMainJsonnet: main,
+		"broken/" + ConceptFileName:      concept("string"),
+		"broken/" + ConceptMainJsonnet:   `{ cm: `,
+		"broken/" + ConceptJsonnetfile:   `{"version": 1, "dependencies": []}`,
+		"novendor/" + ConceptFileName:    concept("string"),
+		"novendor/" + ConceptMainJsonnet: main,
+		"novendor/" + ConceptJsonnetfile: `{"version": 1, "dependencies": [{"source": {"local": {"directory": "lib"}}}]}`,
+	}
+	for name, content := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	report, err := VerifyRepository(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]bool{"good": true, "missing": false, "badinput": false, "broken": false, "novendor": false}
+	if len(report.Results) != len(want) {
+		t.Fatalf("VerifyRepository() results = %v, want %d", report.Results, len(want))
+	}
+	for _, res := range report.Results {
+		if res.Passed() != want[res.Concept] {
+			t.Errorf("VerifyRepository() '%s' passed = %t, want %t (errors: %v)", res.Concept, res.Passed(), want[res.Concept], res.Errors)
+		}
+	}
+	if len(report.Failed()) != 4 {
+		t.Errorf("VerifyRepository() failed = %d, want 4", len(report.Failed()))
+	}
+}
+
+func TestInputType_exampleValue(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   InputType
+		want    ValueType
+		wantErr bool
+	}{
+		{name: "string", input: InputType{Type: ConceptStringInputType, Example: "demo"}, want: StringValueType("demo")},
+		{name: "string without example", input: InputType{Type: ConceptStringInputType}, want: StringValueType("id")},
+		{name: "select", input: InputType{Type: ConceptSelectionInputType, Options: []string{"a", "b"}}, want: StringValueType("a")},
+		{name: "int", input: InputType{Type: ConceptIntInputType, Example: "3"}, want: IntValueType(3)},
+		{name: "invalid int", input: InputType{Type: ConceptIntInputType, Example: "three"}, wantErr: true},
+		{name: "bool", input: InputType{Type: ConceptBoolInputType, Example: "true"}, want: BoolValueType(true)},
+		{name: "unsupported", input: InputType{Type: "float"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.input.exampleValue("id")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("exampleValue() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("exampleValue() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
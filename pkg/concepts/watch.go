@@ -0,0 +1,112 @@
+package concepts
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+const DefaultWatchDebounce = 300 * time.Millisecond
+
+// WatchedConceptFiles and WatchedConceptDirs are the parts of a concept directory that trigger a re-render when they
+// change. Everything else (e.g. the output directory) is ignored.
+var (
+	WatchedConceptFiles = []string{ConceptMainJsonnet, ConceptFileName, ConceptJsonnetfile, "jsonnetfile.lock.json"}
+	WatchedConceptDirs  = []string{ConceptLibDir, ConceptVendorDir}
+)
+
+// WatchConcept watches the concept at path, and calls onChange with the changed files (relative to path) once no
+// further change happened for the debounce duration. Errors while watching are passed to onError, without stopping
+// the watch. It blocks until stop is closed.
+func WatchConcept(path string, debounce time.Duration, stop <-chan struct{}, onChange func(changed []string), onError func(err error)) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	defer watcher.Close()
+
+	// The concept dir itself is watched for its top-level files, which are often replaced rather than written by
+	// editors, so watching the files directly would lose track of them.
+	if err := watcher.Add(path); err != nil {
+		return err
+	}
+	for _, dir := range WatchedConceptDirs {
+		if err := watchRecursively(watcher, filepath.Join(path, dir)); err != nil {
+			return err
+		}
+	}
+
+	changed := map[string]bool{}
+	timer := time.NewTimer(debounce)
+	timer.Stop()
+	for {
+		select {
+		case <-stop:
+			return nil
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			onError(err)
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+			rel, err := filepath.Rel(path, event.Name)
+			if err != nil || !isWatchedConceptPath(rel) {
+				continue
+			}
+			if event.Op&fsnotify.Create == fsnotify.Create {
+				if info, err := os.Stat(event.Name); err == nil && info.IsDir() {
+					if err := watchRecursively(watcher, event.Name); err != nil {
+						onError(err)
+					}
+				}
+			}
+			changed[filepath.ToSlash(rel)] = true
+			timer.Reset(debounce)
+		case <-timer.C:
+			var files []string
+			for file := range changed {
+				files = append(files, file)
+			}
+			sort.Strings(files)
+			changed = map[string]bool{}
+			onChange(files)
+		}
+	}
+}
+
+func isWatchedConceptPath(rel string) bool {
+	rel = filepath.ToSlash(rel)
+	for _, file := range WatchedConceptFiles {
+		if rel == file {
+			return true
+		}
+	}
+	for _, dir := range WatchedConceptDirs {
+		if rel+"/" == dir || strings.HasPrefix(rel, dir) {
+			return true
+		}
+	}
+	return false
+}
+
+func watchRecursively(watcher *fsnotify.Watcher, root string) error {
+	return filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if !info.IsDir() {
+			return nil
+		}
+		return watcher.Add(path)
+	})
+}
@@ -0,0 +1,97 @@
+package concepts
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func Test_isWatchedConceptPath(t *testing.T) {
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{path: ConceptMainJsonnet, want: true},
+		{path: ConceptFileName, want: true},
+		{path: ConceptJsonnetfile, want: true},
+		{path: "jsonnetfile.lock.json", want: true},
+		{path: "lib", want: true},
+		{path: "lib/k.libsonnet", want: true},
+		{path: "vendor/github.com/x/x.libsonnet", want: true},
+		{path: "library.jsonnet"},
+		{path: "output/configmap.yaml"},
+		{path: "output/" + ConceptRenderFileName},
+		{path: "README.md"},
+		{path: "../main.jsonnet"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			if got := isWatchedConceptPath(filepath.FromSlash(tt.path)); got != tt.want {
+				t.Errorf("isWatchedConceptPath() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWatchConcept(t *testing.T) {
+	dir, err := ioutil.TempDir("", "kable-watch")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	for _, name := range []string{ConceptMainJsonnet, "lib/k.libsonnet", "output/configmap.yaml"} {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte("{}"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	stop := make(chan struct{})
+	done := make(chan error)
+	changes := make(chan []string, 10)
+	go func() {
+		done <- WatchConcept(dir, 200*time.Millisecond, stop, func(changed []string) {
+			changes <- changed
+		}, func(err error) {
+			t.Errorf("WatchConcept() unexpected error: %v", err)
+		})
+	}()
+	// Give the watcher time to set up its watches
+	time.Sleep(100 * time.Millisecond)
+
+	// A burst of changes is reported once, without the ignored output directory
+	for i := 0; i < 3; i++ {
+		for _, name := range []string{ConceptMainJsonnet, "lib/k.libsonnet", "output/configmap.yaml"} {
+			if err := ioutil.WriteFile(filepath.Join(dir, filepath.FromSlash(name)), []byte("{ a: 1 }"), 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+
+	want := []string{"lib/k.libsonnet", ConceptMainJsonnet}
+	select {
+	case got := <-changes:
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("WatchConcept() changed = %v, want %v", got, want)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("WatchConcept() reported no change")
+	}
+	select {
+	case got := <-changes:
+		t.Errorf("WatchConcept() reported changes %v again, want a single report", got)
+	case <-time.After(400 * time.Millisecond):
+	}
+
+	close(stop)
+	if err := <-done; err != nil {
+		t.Errorf("WatchConcept() error = %v", err)
+	}
+}
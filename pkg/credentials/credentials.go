@@ -0,0 +1,129 @@
+package credentials
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/mitchellh/mapstructure"
+	"github.com/spf13/viper"
+
+	"github.com/redradrat/kable/pkg/errors"
+)
+
+const (
+	CredentialStoreKey                     = "credentialStore"
+	StoreTypeKey                           = "type"
+	StoreConfigKey                         = "config"
+	FileStoreType          StoreConfigType = "file"
+	GitHelperStoreType     StoreConfigType = "git"
+	MockStoreType          StoreConfigType = "_mock"
+	FileStoreFileName                      = "credentials.enc"
+	FileStoreKeyFileName                   = "credentials.key"
+	FileStorePassphraseEnv                 = "KABLE_CREDENTIALS_PASSPHRASE"
+	refSeparator                           = ":"
+)
+
+// kableDir mirrors repositories.KableDir, which can't be imported here, as repositories depends on this package
+var kableDir = func() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ".kable"
+	}
+	return filepath.Join(home, ".kable")
+}()
+
+var (
+	DefaultFileStorePath    = filepath.Join(kableDir, FileStoreFileName)
+	DefaultFileStoreKeyFile = filepath.Join(kableDir, FileStoreKeyFileName)
+)
+
+// Credential is a secret needed to access a repository. For SSH keys, Password holds the passphrase.
+type Credential struct {
+	Username string `json:"username,omitempty"`
+	Password string `json:"password,omitempty"`
+}
+
+// Store keeps credentials outside of the registry. The registry only holds the opaque references returned by Store.
+type Store interface {
+	// Store saves the credential for the given URL, and returns a reference to it
+	Store(url string, cred Credential) (string, error)
+	Get(ref string) (*Credential, error)
+	Delete(ref string) error
+}
+
+type StoreConfigType string
+
+func (t StoreConfigType) String() string {
+	return string(t)
+}
+
+type StoreConfigMap map[string]interface{}
+
+func (cm StoreConfigMap) Map() map[string]interface{} {
+	return cm
+}
+
+// GetStoreFromConfig returns the configured credential store. Without configuration, an encrypted file store in the
+// kable directory is used.
+func GetStoreFromConfig() (Store, error) {
+	m := viper.GetStringMap(CredentialStoreKey)
+	if len(m) == 0 {
+		m = FileStoreConfigMap("", "")
+	}
+	return StoreConfigMap(m).GetStore()
+}
+
+func (cm StoreConfigMap) GetStore() (Store, error) {
+	switch cm[StoreTypeKey] {
+	case FileStoreType.String():
+		f := FileStore{}
+		if err := mapstructure.Decode(cm[StoreConfigKey], &f); err != nil {
+			return nil, err
+		}
+		if f.Path == "" {
+			f.Path = DefaultFileStorePath
+		}
+		// A passphrase is never part of the settings, but taken from the environment
+		if f.Passphrase = os.Getenv(FileStorePassphraseEnv); f.Passphrase == "" && f.KeyFile == "" {
+			f.KeyFile = DefaultFileStoreKeyFile
+		}
+		return f, nil
+	case GitHelperStoreType.String():
+		return GitHelperStore{}, nil
+	case MockStoreType.String():
+		return MockStore{}, nil
+	default:
+		return nil, errors.InvalidStoreType
+	}
+}
+
+// FileStoreConfigMap returns a config for an encrypted file store. Empty values use the defaults.
+func FileStoreConfigMap(path, keyFile string) StoreConfigMap {
+	return StoreConfigMap{
+		StoreTypeKey:   FileStoreType.String(),
+		StoreConfigKey: map[string]interface{}{"path": path, "keyFile": keyFile},
+	}
+}
+
+func GitHelperStoreConfigMap() StoreConfigMap {
+	return StoreConfigMap{StoreTypeKey: GitHelperStoreType.String()}
+}
+
+func MockStoreConfigMap() StoreConfigMap {
+	return StoreConfigMap{StoreTypeKey: MockStoreType.String()}
+}
+
+func newRef(storeType StoreConfigType, key string) string {
+	return storeType.String() + refSeparator + key
+}
+
+// parseRef returns the store-specific key of the given reference
+func parseRef(storeType StoreConfigType, ref string) (string, error) {
+	prefix := storeType.String() + refSeparator
+	if !strings.HasPrefix(ref, prefix) || len(ref) == len(prefix) {
+		return "", fmt.Errorf("%w: '%s' does not belong to the %s store", errors.InvalidCredentialRefError, ref, storeType)
+	}
+	return strings.TrimPrefix(ref, prefix), nil
+}
@@ -0,0 +1,173 @@
+package credentials
+
+import (
+	goerrors "errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/redradrat/kable/pkg/errors"
+)
+
+func TestFileStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "kable-credentials")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	tests := []struct {
+		name  string
+		store FileStore
+		other FileStore
+	}{
+		{
+			name:  "passphrase",
+			store: FileStore{Path: filepath.Join(dir, "passphrase.enc"), Passphrase: "secret"},
+			other: FileStore{Path: filepath.Join(dir, "passphrase.enc"), Passphrase: "wrong"},
+		},
+		{
+			name:  "key file",
+			store: FileStore{Path: filepath.Join(dir, "keyfile.enc"), KeyFile: filepath.Join(dir, "generated.key")},
+			other: FileStore{Path: filepath.Join(dir, "keyfile.enc"), KeyFile: filepath.Join(dir, "other.key")},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cred := Credential{Username: "user", Password: "pass"}
+			ref, err := tt.store.Store("https://example.com/repo", cred)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !strings.HasPrefix(ref, FileStoreType.String()+refSeparator) {
+				t.Errorf("Store() ref = %v, want file reference", ref)
+			}
+			content, err := ioutil.ReadFile(tt.store.Path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if strings.Contains(string(content), "pass") {
+				t.Errorf("Store() wrote the password in plain text")
+			}
+
+			got, err := tt.store.Get(ref)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(*got, cred) {
+				t.Errorf("Get() = %v, want %v", *got, cred)
+			}
+			if _, err := tt.other.Get(ref); !goerrors.Is(err, errors.CredentialDecryptionError) {
+				t.Errorf("Get() with other secret error = %v, want %v", err, errors.CredentialDecryptionError)
+			}
+
+			if err := tt.store.Delete(ref); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := tt.store.Get(ref); !goerrors.Is(err, errors.CredentialNotFoundError) {
+				t.Errorf("Get() after Delete() error = %v, want %v", err, errors.CredentialNotFoundError)
+			}
+		})
+	}
+}
+
+func TestFileStore_concurrent(t *testing.T) {
+	dir, err := ioutil.TempDir("", "kable-credentials")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	store := FileStore{Path: filepath.Join(dir, "credentials.enc"), KeyFile: filepath.Join(dir, "credentials.key")}
+
+	// Without the lock, concurrent writers lose each other's credentials, or generate different key files
+	var wg sync.WaitGroup
+	refs := make([]string, 4)
+	errs := make([]error, len(refs))
+	for i := range refs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			refs[i], errs[i] = store.Store("https://example.com/repo", Credential{Password: strconv.Itoa(i)})
+		}(i)
+	}
+	wg.Wait()
+
+	for i, ref := range refs {
+		if errs[i] != nil {
+			t.Fatalf("Store() error = %v", errs[i])
+		}
+		got, err := store.Get(ref)
+		if err != nil {
+			t.Fatalf("Get() error = %v", err)
+		}
+		if got.Password != strconv.Itoa(i) {
+			t.Errorf("Get() password = %v, want %v", got.Password, i)
+		}
+	}
+}
+
+func TestGitHelperStore(t *testing.T) {
+	home, err := ioutil.TempDir("", "kable-git-home")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(home)
+	credFile := filepath.Join(home, "git-credentials")
+	gitconfig := "[credential]\n\thelper = store --file " + credFile + "\n"
+	if err := ioutil.WriteFile(filepath.Join(home, ".gitconfig"), []byte(gitconfig), 0644); err != nil {
+		t.Fatal(err)
+	}
+	origHome := os.Getenv("HOME")
+	os.Setenv("HOME", home)
+	defer os.Setenv("HOME", origHome)
+
+	store := GitHelperStore{}
+	cred := Credential{Username: "user", Password: "pass"}
+	ref, err := store.Store("https://example.com/repo", cred)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := store.Get(ref)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(*got, cred) {
+		t.Errorf("Get() = %v, want %v", *got, cred)
+	}
+	if err := store.Delete(ref); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Get(ref); err == nil {
+		t.Errorf("Get() after Delete() expected error")
+	}
+}
+
+func Test_parseRef(t *testing.T) {
+	tests := []struct {
+		ref     string
+		want    string
+		wantErr bool
+	}{
+		{ref: "file:abc", want: "abc"},
+		{ref: "git:abc", wantErr: true},
+		{ref: "file:", wantErr: true},
+		{ref: "abc", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.ref, func(t *testing.T) {
+			got, err := parseRef(FileStoreType, tt.ref)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("parseRef() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("parseRef() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,235 @@
+package credentials
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"golang.org/x/crypto/scrypt"
+
+	"github.com/redradrat/kable/pkg/errors"
+	"github.com/redradrat/kable/pkg/filelock"
+)
+
+const (
+	fileStoreVersion = 1
+	keyFileSize      = 32
+	saltSize         = 16
+)
+
+// FileStore keeps credentials in an AES-GCM encrypted file. The encryption key is derived via scrypt from either the
+// Passphrase, or the content of the KeyFile. A missing KeyFile is generated. Modifications are serialized between
+// processes by a lock file next to the credential file.
+//
+// A KeyFile next to the credential file (as by default) only keeps the credentials from being read out of the
+// credential file alone, e.g. from a backup. It does not protect against anyone able to read the kable directory.
+type FileStore struct {
+	Path       string `mapstructure:"path"`
+	KeyFile    string `mapstructure:"keyFile"`
+	Passphrase string `mapstructure:"-"`
+}
+
+type encryptedFile struct {
+	Version int    `json:"version"`
+	Salt    []byte `json:"salt"`
+	Nonce   []byte `json:"nonce"`
+	Data    []byte `json:"data"`
+}
+
+func (fs FileStore) Store(url string, cred Credential) (string, error) {
+	unlock, err := fs.lock()
+	if err != nil {
+		return "", err
+	}
+	defer unlock()
+
+	creds, err := fs.read()
+	if err != nil {
+		return "", err
+	}
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return "", err
+	}
+	key := hex.EncodeToString(id)
+	creds[key] = cred
+	if err := fs.write(creds); err != nil {
+		return "", err
+	}
+	return newRef(FileStoreType, key), nil
+}
+
+func (fs FileStore) Get(ref string) (*Credential, error) {
+	key, err := parseRef(FileStoreType, ref)
+	if err != nil {
+		return nil, err
+	}
+	creds, err := fs.read()
+	if err != nil {
+		return nil, err
+	}
+	cred, ok := creds[key]
+	if !ok {
+		return nil, fmt.Errorf("%w: '%s'", errors.CredentialNotFoundError, ref)
+	}
+	return &cred, nil
+}
+
+func (fs FileStore) Delete(ref string) error {
+	key, err := parseRef(FileStoreType, ref)
+	if err != nil {
+		return err
+	}
+	unlock, err := fs.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	creds, err := fs.read()
+	if err != nil {
+		return err
+	}
+	if _, ok := creds[key]; !ok {
+		return nil
+	}
+	delete(creds, key)
+	return fs.write(creds)
+}
+
+// lock takes the exclusive lock on the credential file, which has to be held from reading to writing it, and during
+// generation of the key file.
+func (fs FileStore) lock() (func(), error) {
+	return filelock.Lock(fs.Path + ".lock")
+}
+
+func (fs FileStore) secret() ([]byte, error) {
+	if fs.Passphrase != "" {
+		return []byte(fs.Passphrase), nil
+	}
+	if fs.KeyFile == "" {
+		return nil, fmt.Errorf("credential file store needs either a passphrase or a key file")
+	}
+	b, err := ioutil.ReadFile(fs.KeyFile)
+	if err == nil {
+		return b, nil
+	}
+	if !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	b = make([]byte, keyFileSize)
+	if _, err := rand.Read(b); err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(fs.KeyFile), 0700); err != nil {
+		return nil, err
+	}
+	if err := ioutil.WriteFile(fs.KeyFile, b, 0600); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+func (fs FileStore) gcm(salt []byte) (cipher.AEAD, error) {
+	secret, err := fs.secret()
+	if err != nil {
+		return nil, err
+	}
+	key, err := scrypt.Key(secret, salt, 1<<15, 8, 1, 32)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func (fs FileStore) read() (map[string]Credential, error) {
+	creds := map[string]Credential{}
+	b, err := ioutil.ReadFile(fs.Path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return creds, nil
+		}
+		return nil, err
+	}
+
+	ef := encryptedFile{}
+	if err := json.Unmarshal(b, &ef); err != nil {
+		return nil, err
+	}
+	if ef.Version != fileStoreVersion {
+		return nil, fmt.Errorf("unsupported credential file version %d", ef.Version)
+	}
+	gcm, err := fs.gcm(ef.Salt)
+	if err != nil {
+		return nil, err
+	}
+	plain, err := gcm.Open(nil, ef.Nonce, ef.Data, nil)
+	if err != nil {
+		return nil, errors.CredentialDecryptionError
+	}
+	if err := json.Unmarshal(plain, &creds); err != nil {
+		return nil, err
+	}
+	return creds, nil
+}
+
+func (fs FileStore) write(creds map[string]Credential) error {
+	plain, err := json.Marshal(creds)
+	if err != nil {
+		return err
+	}
+
+	ef := encryptedFile{Version: fileStoreVersion, Salt: make([]byte, saltSize)}
+	if _, err := io.ReadFull(rand.Reader, ef.Salt); err != nil {
+		return err
+	}
+	gcm, err := fs.gcm(ef.Salt)
+	if err != nil {
+		return err
+	}
+	ef.Nonce = make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, ef.Nonce); err != nil {
+		return err
+	}
+	ef.Data = gcm.Seal(nil, ef.Nonce, plain, nil)
+
+	b, err := json.Marshal(ef)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(fs.Path), 0700); err != nil {
+		return err
+	}
+
+	// Replace the file atomically, so readers without the lock never see a partially written file
+	tmp, err := ioutil.TempFile(filepath.Dir(fs.Path), filepath.Base(fs.Path)+".tmp-")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(b); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Rename(tmp.Name(), fs.Path); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return nil
+}
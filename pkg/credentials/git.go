@@ -0,0 +1,88 @@
+package credentials
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/redradrat/kable/pkg/errors"
+)
+
+// GitHelperStore delegates to the credential helpers configured for git, via 'git credential'. Credentials are keyed
+// by URL, so references contain the URL.
+type GitHelperStore struct{}
+
+// GitHelperRef returns the reference to the credentials of git for the given URL
+func GitHelperRef(url string) string {
+	return newRef(GitHelperStoreType, url)
+}
+
+func (gs GitHelperStore) Store(url string, cred Credential) (string, error) {
+	if _, err := gitCredential("approve", url, cred); err != nil {
+		return "", err
+	}
+	return GitHelperRef(url), nil
+}
+
+func (gs GitHelperStore) Get(ref string) (*Credential, error) {
+	url, err := parseRef(GitHelperStoreType, ref)
+	if err != nil {
+		return nil, err
+	}
+	out, err := gitCredential("fill", url, Credential{})
+	if err != nil {
+		return nil, fmt.Errorf("%w: '%s': %v", errors.CredentialNotFoundError, ref, err)
+	}
+
+	cred := Credential{}
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		parts := strings.SplitN(scanner.Text(), "=", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		switch parts[0] {
+		case "username":
+			cred.Username = parts[1]
+		case "password":
+			cred.Password = parts[1]
+		}
+	}
+	return &cred, nil
+}
+
+func (gs GitHelperStore) Delete(ref string) error {
+	url, err := parseRef(GitHelperStoreType, ref)
+	if err != nil {
+		return err
+	}
+	_, err = gitCredential("reject", url, Credential{})
+	return err
+}
+
+// gitCredential runs 'git credential <action>' for the given URL. Prompting is disabled, so missing credentials fail.
+func gitCredential(action, url string, cred Credential) ([]byte, error) {
+	var in bytes.Buffer
+	fmt.Fprintf(&in, "url=%s\n", url)
+	if cred.Username != "" {
+		fmt.Fprintf(&in, "username=%s\n", cred.Username)
+	}
+	if cred.Password != "" {
+		fmt.Fprintf(&in, "password=%s\n", cred.Password)
+	}
+	in.WriteString("\n")
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command("git", "credential", action)
+	cmd.Stdin = &in
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("git credential %s failed: %v: %s", action, err, strings.TrimSpace(stderr.String()))
+	}
+	return stdout.Bytes(), nil
+}
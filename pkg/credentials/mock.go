@@ -0,0 +1,48 @@
+package credentials
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/redradrat/kable/pkg/errors"
+)
+
+var (
+	mockCredentials   = map[string]Credential{}
+	mockCredentialsMu sync.Mutex
+)
+
+// MockStore keeps credentials in memory, for tests
+type MockStore struct{}
+
+func (m MockStore) Store(url string, cred Credential) (string, error) {
+	mockCredentialsMu.Lock()
+	defer mockCredentialsMu.Unlock()
+	mockCredentials[url] = cred
+	return newRef(MockStoreType, url), nil
+}
+
+func (m MockStore) Get(ref string) (*Credential, error) {
+	url, err := parseRef(MockStoreType, ref)
+	if err != nil {
+		return nil, err
+	}
+	mockCredentialsMu.Lock()
+	defer mockCredentialsMu.Unlock()
+	cred, ok := mockCredentials[url]
+	if !ok {
+		return nil, fmt.Errorf("%w: '%s'", errors.CredentialNotFoundError, ref)
+	}
+	return &cred, nil
+}
+
+func (m MockStore) Delete(ref string) error {
+	url, err := parseRef(MockStoreType, ref)
+	if err != nil {
+		return err
+	}
+	mockCredentialsMu.Lock()
+	defer mockCredentialsMu.Unlock()
+	delete(mockCredentials, url)
+	return nil
+}
@@ -3,22 +3,43 @@ package errors
 import "errors"
 
 var (
-	StaleRepoCacheIndexError       = errors.New("attempted to write stale cache")
-	RepositoryInvalidError         = errors.New("given repository is not a valid kable repository")
-	RepositoryAlreadyExistsError   = errors.New("repository is already configured")
-	RepositoryUnknownError         = errors.New("repository is unknown")
-	RepositoryNotInitializedError  = errors.New("repository is not yet initialized")
-	ConfigNotInitializedError      = errors.New("currentConfig is not yet initialized")
-	ConfigAlreadyInitializedError  = errors.New("currentConfig is already initialized")
-	ConceptTypeUnsupportedError    = errors.New("given concept type is not supported")
-	RenderTargetUnsupportedError   = errors.New("desired render target is not supported")
-	InvalidConceptIdentifierError  = errors.New("given concept identifier is invalid")
-	ConceptDirInvalidError         = errors.New("directory is not a concept directory")
-	InvalidRenderNameError         = errors.New("given app name is invalid (only allowed: 'a-z', '-', '_')")
-	ValueTypeNotSupported          = errors.New("given value type is not supported")
-	NotDirError                    = errors.New("given path is not a directory")
-	UnsupportedURISchemeError      = errors.New("given URI scheme is not supported")
-	NotHelmChartError              = errors.New("given repository path is not a valid helm chart")
-	InvalidStoreType               = errors.New("invalid store type")
-	MultipleRegistriesInStoreError = errors.New("multiple registries detected in store")
+	StaleRepoCacheIndexError          = errors.New("attempted to write stale cache")
+	RepositoryInvalidError            = errors.New("given repository is not a valid kable repository")
+	RepositoryAlreadyExistsError      = errors.New("repository is already configured")
+	RepositoryUnknownError            = errors.New("repository is unknown")
+	RepositoryNotInitializedError     = errors.New("repository is not yet initialized")
+	ConfigNotInitializedError         = errors.New("currentConfig is not yet initialized")
+	ConfigAlreadyInitializedError     = errors.New("currentConfig is already initialized")
+	ConceptTypeUnsupportedError       = errors.New("given concept type is not supported")
+	RenderTargetUnsupportedError      = errors.New("desired render target is not supported")
+	InvalidConceptIdentifierError     = errors.New("given concept identifier is invalid")
+	ConceptDirInvalidError            = errors.New("directory is not a concept directory")
+	InvalidRenderNameError            = errors.New("given app name is invalid (only allowed: 'a-z', '-', '_')")
+	ValueTypeNotSupported             = errors.New("given value type is not supported")
+	NotDirError                       = errors.New("given path is not a directory")
+	UnsupportedURISchemeError         = errors.New("given URI scheme is not supported")
+	NotHelmChartError                 = errors.New("given repository path is not a valid helm chart")
+	InvalidStoreType                  = errors.New("invalid store type")
+	MultipleRegistriesInStoreError    = errors.New("multiple registries detected in store")
+	InvalidValueReferenceError        = errors.New("given value reference is invalid (expected: 'ref+<scheme>://<path>')")
+	UnknownValueResolverError         = errors.New("no value resolver registered for scheme")
+	ValueReferencesDisabledError      = errors.New("value references are not allowed for this render")
+	UnknownEnvironmentError           = errors.New("environment is not defined in values file")
+	RenderInfoVersionUnsupportedError = errors.New("renderinfo version is not supported")
+	HookFailedError                   = errors.New("concept hook failed")
+	HooksDisabledError                = errors.New("concept hooks are not allowed for this render")
+	InvalidCredentialRefError         = errors.New("given credential reference is invalid")
+	CredentialNotFoundError           = errors.New("credential not found")
+	CredentialDecryptionError         = errors.New("unable to decrypt credentials (wrong passphrase or key file?)")
+	CredentialStoreNotSharedError     = errors.New("the default credential store is local to this host, but the registry is shared")
+	InvalidGitRefError                = errors.New("given git ref is invalid")
+	OfflineError                      = errors.New("network access is disabled in offline mode")
+	RepositoryCacheMissingError       = errors.New("repository is not cached")
+	SignatureVerificationError        = errors.New("signature verification failed")
+	InvalidVerificationError          = errors.New("given signature verification is invalid")
+	InputTypeUnsupportedError         = errors.New("given input type is not supported")
+	VendorDirMissingError             = errors.New("concept dependencies are not vendored")
+	BundleInvalidError                = errors.New("given bundle is invalid")
+	BundleChecksumError               = errors.New("bundle content does not match its checksums")
+	RegistryConflictError             = errors.New("registry has been modified concurrently")
 )
@@ -0,0 +1,26 @@
+package filelock
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// Lock blocks until it holds an exclusive lock on the lock file at path, and returns the function releasing it. The
+// lock file and its directory are created, if missing.
+func Lock(path string) (func(), error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0600)
+	if err != nil {
+		return nil, err
+	}
+	if err := lockFile(f); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return func() {
+		_ = unlockFile(f)
+		f.Close()
+	}, nil
+}
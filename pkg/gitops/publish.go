@@ -0,0 +1,238 @@
+package gitops
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+
+	"github.com/redradrat/kable/pkg/concepts"
+	"github.com/redradrat/kable/pkg/errors"
+	"github.com/redradrat/kable/pkg/repositories"
+)
+
+const (
+	DefaultBranch      = "master"
+	DefaultAuthorName  = "kable"
+	DefaultAuthorEmail = "kable@localhost"
+	remoteName         = "origin"
+)
+
+// PublishOpts define where, and as whom, a render is published.
+type PublishOpts struct {
+	// URL of the target git repository. Authentication is looked up via repositories.AuthMethod.
+	URL string
+	// Branch to commit to. It is created, if it does not exist yet. Defaults to DefaultBranch.
+	Branch string
+	// Path within the repository, the render is written to. Its previous content is replaced.
+	Path        string
+	AuthorName  string
+	AuthorEmail string
+}
+
+// PublishResult describes the outcome of a publish. Without any changes, no commit is created.
+type PublishResult struct {
+	Commit  string
+	Changed bool
+}
+
+// Publish writes the given render (including its renderinfo) to the configured path of the target repository, commits
+// and pushes it.
+func Publish(render *concepts.Render, opts PublishOpts) (*PublishResult, error) {
+	if opts.URL == "" {
+		return nil, fmt.Errorf("no target repository given")
+	}
+	if repositories.IsOffline() {
+		return nil, fmt.Errorf("unable to publish to '%s': %w", opts.URL, errors.OfflineError)
+	}
+	dest, err := cleanRepoPath(opts.Path)
+	if err != nil {
+		return nil, err
+	}
+	if opts.Branch == "" {
+		opts.Branch = DefaultBranch
+	}
+	ri, err := render.RenderInfo()
+	if err != nil {
+		return nil, fmt.Errorf("unable to read renderinfo of render: %w", err)
+	}
+
+	auth, err := repositories.AuthMethod(opts.URL)
+	if err != nil {
+		return nil, err
+	}
+
+	dir, err := ioutil.TempDir("", "kable-publish")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	repo, err := checkoutBranch(dir, opts.URL, opts.Branch, auth)
+	if err != nil {
+		return nil, err
+	}
+	wt, err := repo.Worktree()
+	if err != nil {
+		return nil, err
+	}
+
+	// Replace the previous content, so files no longer part of the render are removed
+	target := filepath.Join(dir, filepath.FromSlash(dest))
+	if err := os.RemoveAll(target); err != nil {
+		return nil, err
+	}
+	if err := render.Write(target); err != nil {
+		return nil, err
+	}
+
+	status, err := wt.Status()
+	if err != nil {
+		return nil, err
+	}
+	if status.IsClean() {
+		return &PublishResult{}, nil
+	}
+	for file, s := range status {
+		if s.Worktree == git.Deleted {
+			_, err = wt.Remove(file)
+		} else {
+			_, err = wt.Add(file)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	msg, err := commitMessage(ri, dest)
+	if err != nil {
+		return nil, err
+	}
+	hash, err := wt.Commit(msg, &git.CommitOptions{Author: signature(opts)})
+	if err != nil {
+		return nil, err
+	}
+
+	ref := plumbing.NewBranchReferenceName(opts.Branch)
+	if err := repo.Push(&git.PushOptions{
+		RemoteName: remoteName,
+		RefSpecs:   []config.RefSpec{config.RefSpec(ref + ":" + ref)},
+		Auth:       auth,
+	}); err != nil {
+		return nil, fmt.Errorf("unable to push to '%s': %w", opts.URL, err)
+	}
+
+	return &PublishResult{Commit: hash.String(), Changed: true}, nil
+}
+
+// checkoutBranch initializes a repository in dir, and checks out the given branch of the remote. Empty remotes and
+// missing branches result in a new, orphaned branch.
+func checkoutBranch(dir, url, branch string, auth transport.AuthMethod) (*git.Repository, error) {
+	repo, err := git.PlainInit(dir, false)
+	if err != nil {
+		return nil, err
+	}
+	remote, err := repo.CreateRemote(&config.RemoteConfig{Name: remoteName, URLs: []string{url}})
+	if err != nil {
+		return nil, err
+	}
+
+	ref := plumbing.NewBranchReferenceName(branch)
+	if err := repo.Storer.SetReference(plumbing.NewSymbolicReference(plumbing.HEAD, ref)); err != nil {
+		return nil, err
+	}
+
+	refs, err := remote.List(&git.ListOptions{Auth: auth})
+	if err == transport.ErrEmptyRemoteRepository {
+		return repo, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to list references of '%s': %w", url, err)
+	}
+	exists := false
+	for _, r := range refs {
+		exists = exists || r.Name() == ref
+	}
+	if !exists {
+		return repo, nil
+	}
+
+	remoteRef := plumbing.NewRemoteReferenceName(remoteName, branch)
+	if err := repo.Fetch(&git.FetchOptions{
+		RemoteName: remoteName,
+		RefSpecs:   []config.RefSpec{config.RefSpec("+" + ref + ":" + remoteRef)},
+		Auth:       auth,
+	}); err != nil && err != git.NoErrAlreadyUpToDate {
+		return nil, err
+	}
+	fetched, err := repo.Reference(remoteRef, true)
+	if err != nil {
+		return nil, err
+	}
+	if err := repo.Storer.SetReference(plumbing.NewHashReference(ref, fetched.Hash())); err != nil {
+		return nil, err
+	}
+	wt, err := repo.Worktree()
+	if err != nil {
+		return nil, err
+	}
+	if err := wt.Checkout(&git.CheckoutOptions{Branch: ref}); err != nil {
+		return nil, err
+	}
+	return repo, nil
+}
+
+// cleanRepoPath validates, that the given path stays within the repository
+func cleanRepoPath(p string) (string, error) {
+	clean := path.Clean("/" + filepath.ToSlash(p))
+	if clean == "/" || strings.HasPrefix(clean, "/.git/") || clean == "/.git" {
+		return "", fmt.Errorf("invalid publish path '%s'", p)
+	}
+	return strings.TrimPrefix(clean, "/"), nil
+}
+
+func commitMessage(ri *concepts.RenderInfoV2, dest string) (string, error) {
+	digest, err := ri.ValuesDigest()
+	if err != nil {
+		return "", err
+	}
+	concept := ri.Concept
+	if concept == "" {
+		concept = "unknown concept"
+	}
+
+	lines := []string{
+		fmt.Sprintf("Render %s into %s", concept, dest),
+		"",
+		"Concept: " + concept,
+	}
+	if ri.Origin != nil {
+		origin := ri.Origin.Repository + "@" + ri.Origin.Ref
+		if ri.Origin.Commit != "" {
+			origin += " (" + ri.Origin.Commit + ")"
+		}
+		lines = append(lines, "Origin: "+origin)
+	}
+	lines = append(lines, "Values: "+digest)
+	return strings.Join(lines, "\n") + "\n", nil
+}
+
+func signature(opts PublishOpts) *object.Signature {
+	sig := &object.Signature{Name: opts.AuthorName, Email: opts.AuthorEmail, When: time.Now()}
+	if sig.Name == "" {
+		sig.Name = DefaultAuthorName
+	}
+	if sig.Email == "" {
+		sig.Email = DefaultAuthorEmail
+	}
+	return sig
+}
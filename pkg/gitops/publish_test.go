@@ -0,0 +1,99 @@
+package gitops
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/spf13/viper"
+
+	"github.com/redradrat/kable/pkg/concepts"
+	"github.com/redradrat/kable/pkg/repositories"
+)
+
+func TestPublish(t *testing.T) {
+	viper.Set(repositories.StoreKey, repositories.MockStoreConfigMap().Map())
+	remote, err := ioutil.TempDir("", "kable-gitops")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(remote)
+	bare, err := git.PlainInit(remote, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	render, err := concepts.RenderConcept("../../e2e-test/testconcept1", &concepts.RenderValues{"instanceName": concepts.StringValueType("test"), "nameSelection": concepts.StringValueType("Option 1")}, concepts.YamlTargetType, concepts.RenderOpts{Local: true, NoCache: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts := PublishOpts{URL: remote, Branch: "deploy", Path: "clusters/dev/test"}
+
+	// The first publish has to cope with an empty remote
+	first, err := Publish(render, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !first.Changed {
+		t.Fatalf("Publish() expected changes for empty remote")
+	}
+	ref, err := bare.Reference(plumbing.NewBranchReferenceName("deploy"), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ref.Hash().String() != first.Commit {
+		t.Errorf("Publish() remote branch at %s, want %s", ref.Hash(), first.Commit)
+	}
+	commit, err := bare.CommitObject(ref.Hash())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(commit.Message, "Values: ") {
+		t.Errorf("Publish() commit message lacks values digest: %s", commit.Message)
+	}
+	tree, err := commit.Tree()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tree.File("clusters/dev/test/" + concepts.ConceptRenderFileName); err != nil {
+		t.Errorf("Publish() expected renderinfo in published path: %v", err)
+	}
+
+	// Publishing the same render again must not create a commit
+	second, err := Publish(render, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if second.Changed {
+		t.Errorf("Publish() expected no changes when publishing the same render")
+	}
+}
+
+func Test_cleanRepoPath(t *testing.T) {
+	tests := []struct {
+		path    string
+		want    string
+		wantErr bool
+	}{
+		{path: "clusters/dev", want: "clusters/dev"},
+		{path: "/clusters/../dev/", want: "dev"},
+		{path: "../../outside", want: "outside"},
+		{path: "", wantErr: true},
+		{path: ".git/hooks", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			got, err := cleanRepoPath(tt.path)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("cleanRepoPath() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("cleanRepoPath() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
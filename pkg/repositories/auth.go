@@ -0,0 +1,412 @@
+package repositories
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	"github.com/go-git/go-git/v5/plumbing/transport/http"
+	"github.com/go-git/go-git/v5/plumbing/transport/ssh"
+	"github.com/spf13/viper"
+
+	"github.com/redradrat/kable/pkg/credentials"
+	"github.com/redradrat/kable/pkg/errors"
+)
+
+const (
+	defaultSSHUser       = "git"
+	defaultTokenUser     = "x-access-token"
+	BearerTokenAuthStyle = "bearer"
+	BasicTokenAuthStyle  = "basic"
+)
+
+// Auth is the authentication configured for a repository URL. The secret itself is kept in the credential store, and
+// only referenced by Ref.
+type Auth struct {
+	Ref   string     `json:"ref,omitempty"`
+	SSH   *SSHAuth   `json:"ssh,omitempty"`
+	Token *TokenAuth `json:"token,omitempty"`
+	// CredentialHelper delegates to 'git credential fill', reusing whatever git has configured
+	CredentialHelper bool `json:"credentialHelper,omitempty"`
+	// Basic is the legacy, base64 encoded AuthPair. It is only read, until migrated with MigrateAuths.
+	Basic string `json:"basic,omitempty"`
+}
+
+// SSHAuth references the key used for SSH URLs. Only the path to the key file is stored, never the key itself.
+type SSHAuth struct {
+	// User defaults to the user of the URL, or 'git'
+	User string `json:"user,omitempty"`
+	// KeyFile is the path to a private key. If empty, the ssh-agent is used.
+	KeyFile string `json:"keyFile,omitempty"`
+	// Passphrase is the legacy, base64 encoded passphrase for an encrypted KeyFile. It is only read, until migrated
+	// with MigrateAuths.
+	Passphrase string `json:"passphrase,omitempty"`
+	// KnownHosts is the path to a known_hosts file. Defaults to $SSH_KNOWN_HOSTS, or ~/.ssh/known_hosts.
+	KnownHosts string `json:"knownHosts,omitempty"`
+}
+
+// TokenAuth references an access token, that is read from an environment variable or a file on every use.
+type TokenAuth struct {
+	// Style is either 'bearer' (Authorization: Bearer <token>), or 'basic' (the token as password). Defaults to basic.
+	Style string `json:"style,omitempty"`
+	// Username for the basic style. Defaults to 'x-access-token'.
+	Username string `json:"username,omitempty"`
+	Env      string `json:"env,omitempty"`
+	File     string `json:"file,omitempty"`
+}
+
+func (ta TokenAuth) Validate() error {
+	if (ta.Env == "") == (ta.File == "") {
+		return fmt.Errorf("token auth needs either an environment variable or a file")
+	}
+	if ta.Style != "" && ta.Style != BearerTokenAuthStyle && ta.Style != BasicTokenAuthStyle {
+		return fmt.Errorf("unsupported token auth style '%s' (supported: %s, %s)", ta.Style, BearerTokenAuthStyle, BasicTokenAuthStyle)
+	}
+	return nil
+}
+
+// Token reads the referenced token
+func (ta TokenAuth) Token() (string, error) {
+	var token string
+	if ta.Env != "" {
+		token = os.Getenv(ta.Env)
+	} else {
+		b, err := ioutil.ReadFile(expandHome(ta.File))
+		if err != nil {
+			return "", fmt.Errorf("unable to read token: %w", err)
+		}
+		token = string(b)
+	}
+	token = strings.TrimSpace(token)
+	if token == "" {
+		return "", fmt.Errorf("token is empty (%s)", ta.source())
+	}
+	return token, nil
+}
+
+func (ta TokenAuth) source() string {
+	if ta.Env != "" {
+		return "$" + ta.Env
+	}
+	return ta.File
+}
+
+func tokenAuthMethod(ta TokenAuth) (transport.AuthMethod, error) {
+	if err := ta.Validate(); err != nil {
+		return nil, err
+	}
+	token, err := ta.Token()
+	if err != nil {
+		return nil, err
+	}
+	if ta.Style == BearerTokenAuthStyle {
+		return &http.TokenAuth{Token: token}, nil
+	}
+	user := ta.Username
+	if user == "" {
+		user = defaultTokenUser
+	}
+	return &http.BasicAuth{Username: user, Password: token}, nil
+}
+
+type AuthPair struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+// IsSSHURL returns whether the given git URL is accessed via SSH, e.g. 'git@github.com:org/repo.git'
+func IsSSHURL(url string) bool {
+	ep, err := transport.NewEndpoint(url)
+	if err != nil {
+		return false
+	}
+	return ep.Protocol == "ssh"
+}
+
+// AuthMethod returns the configured authentication for the given git URL, or nil if there is none. Without configured
+// authentication, SSH URLs fall back to the ssh-agent.
+func AuthMethod(url string) (transport.AuthMethod, error) {
+	reg, err := Registry()
+	if err != nil {
+		return nil, err
+	}
+	val, ok := reg.Auths[trimUrl(url)]
+	if !ok {
+		return nil, nil
+	}
+
+	if val.Token != nil {
+		return tokenAuthMethod(*val.Token)
+	}
+	if val.CredentialHelper {
+		cred, err := credentials.GitHelperStore{}.Get(credentials.GitHelperRef(trimUrl(url)))
+		if err != nil {
+			return nil, err
+		}
+		return &http.BasicAuth{Username: cred.Username, Password: cred.Password}, nil
+	}
+
+	cred, err := authCredential(val)
+	if err != nil {
+		return nil, err
+	}
+	if val.SSH != nil {
+		var passphrase string
+		if cred != nil {
+			passphrase = cred.Password
+		}
+		return sshAuthMethod(url, *val.SSH, passphrase)
+	}
+	if cred == nil {
+		return nil, nil
+	}
+	return &http.BasicAuth{
+		Username: cred.Username,
+		Password: cred.Password,
+	}, nil
+}
+
+// authCredential returns the secret of the given auth from the credential store, or from not yet migrated legacy
+// fields. It returns nil, if the auth has no secret.
+func authCredential(auth Auth) (*credentials.Credential, error) {
+	if auth.Ref != "" {
+		store, err := credentials.GetStoreFromConfig()
+		if err != nil {
+			return nil, err
+		}
+		return store.Get(auth.Ref)
+	}
+
+	if auth.Basic != "" {
+		var pair AuthPair
+		b, err := base64.StdEncoding.DecodeString(auth.Basic)
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(b, &pair); err != nil {
+			return nil, err
+		}
+		return &credentials.Credential{Username: pair.Username, Password: pair.Password}, nil
+	}
+	if auth.SSH != nil && auth.SSH.Passphrase != "" {
+		passphrase, err := base64.StdEncoding.DecodeString(auth.SSH.Passphrase)
+		if err != nil {
+			return nil, err
+		}
+		return &credentials.Credential{Password: string(passphrase)}, nil
+	}
+	return nil, nil
+}
+
+func sshAuthMethod(url string, sa SSHAuth, passphrase string) (transport.AuthMethod, error) {
+	user := sa.User
+	if user == "" {
+		if ep, err := transport.NewEndpoint(url); err == nil && ep.User != "" {
+			user = ep.User
+		} else {
+			user = defaultSSHUser
+		}
+	}
+
+	var helper *ssh.HostKeyCallbackHelper
+	var auth transport.AuthMethod
+	if sa.KeyFile != "" {
+		keys, err := ssh.NewPublicKeysFromFile(user, expandHome(sa.KeyFile), passphrase)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read ssh key '%s': %w", sa.KeyFile, err)
+		}
+		helper, auth = &keys.HostKeyCallbackHelper, keys
+	} else {
+		agent, err := ssh.NewSSHAgentAuth(user)
+		if err != nil {
+			return nil, fmt.Errorf("unable to use ssh-agent: %w", err)
+		}
+		helper, auth = &agent.HostKeyCallbackHelper, agent
+	}
+
+	var knownHosts []string
+	if sa.KnownHosts != "" {
+		knownHosts = append(knownHosts, expandHome(sa.KnownHosts))
+	}
+	callback, err := ssh.NewKnownHostsCallback(knownHosts...)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read known_hosts: %w", err)
+	}
+	helper.HostKeyCallback = callback
+
+	return auth, nil
+}
+
+func expandHome(path string) string {
+	if path == "~" || strings.HasPrefix(path, "~/") {
+		return filepath.Join(homeDir(), strings.TrimPrefix(path, "~"))
+	}
+	return path
+}
+
+// StoreRepoAuth saves the given basic auth for the URL in the credential store, and references it in the registry
+func StoreRepoAuth(url string, pair AuthPair) (RegistryModification, error) {
+	ref, err := storeCredential(url, credentials.Credential{Username: pair.Username, Password: pair.Password})
+	if err != nil {
+		return nil, err
+	}
+	return storeAuth(url, Auth{Ref: ref}), nil
+}
+
+// StoreRepoSSHAuth stores a reference to the key used for the given SSH URL. The key file has to exist. A passphrase is
+// saved in the credential store.
+func StoreRepoSSHAuth(url string, sa SSHAuth, passphrase string) (RegistryModification, error) {
+	if !IsSSHURL(url) {
+		return nil, fmt.Errorf("'%s' is not an ssh URL", url)
+	}
+	if sa.KeyFile != "" {
+		abs, err := filepath.Abs(expandHome(sa.KeyFile))
+		if err != nil {
+			return nil, err
+		}
+		if _, err := os.Stat(abs); err != nil {
+			return nil, fmt.Errorf("unable to use ssh key: %w", err)
+		}
+		sa.KeyFile = abs
+	}
+	auth := Auth{SSH: &sa}
+	if passphrase != "" {
+		ref, err := storeCredential(url, credentials.Credential{Password: passphrase})
+		if err != nil {
+			return nil, err
+		}
+		auth.Ref = ref
+	}
+	return storeAuth(url, auth), nil
+}
+
+// StoreRepoTokenAuth references a token for the given URL. The token itself is never stored.
+func StoreRepoTokenAuth(url string, ta TokenAuth) (RegistryModification, error) {
+	if err := ta.Validate(); err != nil {
+		return nil, err
+	}
+	if ta.File != "" {
+		abs, err := filepath.Abs(expandHome(ta.File))
+		if err != nil {
+			return nil, err
+		}
+		ta.File = abs
+	}
+	return storeAuth(url, Auth{Token: &ta}), nil
+}
+
+// StoreRepoCredentialHelperAuth delegates authentication for the given URL to the credential helpers of git
+func StoreRepoCredentialHelperAuth(url string) RegistryModification {
+	return storeAuth(url, Auth{CredentialHelper: true})
+}
+
+func storeCredential(url string, cred credentials.Credential) (string, error) {
+	// Registries in etcd are shared by all servers, while the default credential store only exists on this host, so
+	// references to it would not resolve anywhere else
+	if viper.GetStringMap(StoreKey)[StoreTypeKey] == EtcdStoreType.String() && len(viper.GetStringMap(credentials.CredentialStoreKey)) == 0 {
+		return "", fmt.Errorf("%w: configure '%s' explicitly, e.g. with a file on a shared volume", errors.CredentialStoreNotSharedError, credentials.CredentialStoreKey)
+	}
+	store, err := credentials.GetStoreFromConfig()
+	if err != nil {
+		return "", err
+	}
+	return store.Store(trimUrl(url), cred)
+}
+
+// MigrateAuths moves all legacy, base64 encoded secrets of the registry into the credential store. It returns the
+// modification replacing them with references, and the migrated URLs.
+func MigrateAuths() (RegistryModification, []string, error) {
+	reg, err := Registry()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	migrated := Auths{}
+	var urls []string
+	for url, auth := range reg.Auths {
+		if auth.Basic == "" && (auth.SSH == nil || auth.SSH.Passphrase == "") {
+			continue
+		}
+		cred, err := authCredential(Auth{Basic: auth.Basic, SSH: auth.SSH})
+		if err != nil {
+			return nil, nil, fmt.Errorf("unable to read auth of '%s': %w", url, err)
+		}
+		ref, err := storeCredential(url, *cred)
+		if err != nil {
+			return nil, nil, err
+		}
+		auth.Ref = ref
+		auth.Basic = ""
+		if auth.SSH != nil {
+			sa := *auth.SSH
+			sa.Passphrase = ""
+			auth.SSH = &sa
+		}
+		migrated[url] = auth
+		urls = append(urls, url)
+	}
+	sort.Strings(urls)
+
+	return func(registry RepoRegistry) RepoRegistry {
+		for url, auth := range migrated {
+			registry = storeAuth(url, auth)(registry)
+		}
+		return registry
+	}, urls, nil
+}
+
+func storeAuth(url string, auth Auth) RegistryModification {
+	return func(registry RepoRegistry) RepoRegistry {
+		if registry.Auths == nil {
+			registry.Auths = Auths{}
+		}
+		registry.Auths[trimUrl(url)] = auth
+		return registry
+	}
+}
+
+// DiscardAuthCredentials deletes the credentials stored for the auths set by the given modifications, e.g. when the
+// registry could not be updated with them.
+func DiscardAuthCredentials(mods ...RegistryModification) error {
+	reg := RepoRegistry{}
+	for _, mod := range mods {
+		reg = mod(reg)
+	}
+	var refs []string
+	for _, auth := range reg.Auths {
+		if auth.Ref != "" {
+			refs = append(refs, auth.Ref)
+		}
+	}
+	if len(refs) == 0 {
+		return nil
+	}
+
+	store, err := credentials.GetStoreFromConfig()
+	if err != nil {
+		return err
+	}
+	for _, ref := range refs {
+		if err := store.Delete(ref); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func RepoAuthExists(url string) (bool, error) {
+	reg, err := Registry()
+	if err != nil {
+		return false, err
+	}
+	if _, ok := reg.Auths[trimUrl(url)]; ok {
+		return true, nil
+	}
+	return false, nil
+}
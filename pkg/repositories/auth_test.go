@@ -0,0 +1,246 @@
+package repositories
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	goerrors "errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	"github.com/go-git/go-git/v5/plumbing/transport/http"
+	"github.com/go-git/go-git/v5/plumbing/transport/ssh"
+	"github.com/spf13/viper"
+
+	"github.com/redradrat/kable/pkg/credentials"
+	"github.com/redradrat/kable/pkg/errors"
+)
+
+func TestIsSSHURL(t *testing.T) {
+	tests := []struct {
+		url  string
+		want bool
+	}{
+		{url: DemoSshUrl, want: true},
+		{url: "ssh://git@github.com/redradrat/kable.git", want: true},
+		{url: DemoHttpsUrl},
+		{url: "file:///srv/git/concepts"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			if got := IsSSHURL(tt.url); got != tt.want {
+				t.Errorf("IsSSHURL() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_sshAuthMethod(t *testing.T) {
+	dir, err := ioutil.TempDir("", "kable-ssh")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	block := &pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)}
+	encrypted, err := x509.EncryptPEMBlock(rand.Reader, block.Type, block.Bytes, []byte("secret"), x509.PEMCipherAES256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	plainKey := filepath.Join(dir, "id_rsa")
+	encryptedKey := filepath.Join(dir, "id_rsa_encrypted")
+	knownHosts := filepath.Join(dir, "known_hosts")
+	for path, content := range map[string][]byte{plainKey: pem.EncodeToMemory(block), encryptedKey: pem.EncodeToMemory(encrypted), knownHosts: nil} {
+		if err := ioutil.WriteFile(path, content, 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name       string
+		auth       SSHAuth
+		passphrase string
+		wantUser   string
+		wantErr    bool
+	}{
+		{name: "key file", auth: SSHAuth{KeyFile: plainKey, KnownHosts: knownHosts}, wantUser: "git"},
+		{name: "explicit user", auth: SSHAuth{User: "deploy", KeyFile: plainKey, KnownHosts: knownHosts}, wantUser: "deploy"},
+		{name: "passphrase", auth: SSHAuth{KeyFile: encryptedKey, KnownHosts: knownHosts}, passphrase: "secret", wantUser: "git"},
+		{name: "missing passphrase", auth: SSHAuth{KeyFile: encryptedKey, KnownHosts: knownHosts}, wantErr: true},
+		{name: "missing key", auth: SSHAuth{KeyFile: filepath.Join(dir, "missing"), KnownHosts: knownHosts}, wantErr: true},
+		{name: "missing known_hosts", auth: SSHAuth{KeyFile: plainKey, KnownHosts: filepath.Join(dir, "missing")}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := sshAuthMethod(DemoSshUrl, tt.auth, tt.passphrase)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("sshAuthMethod() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			keys, ok := got.(*ssh.PublicKeys)
+			if !ok {
+				t.Fatalf("sshAuthMethod() got = %T, want *ssh.PublicKeys", got)
+			}
+			if keys.User != tt.wantUser {
+				t.Errorf("sshAuthMethod() user = %v, want %v", keys.User, tt.wantUser)
+			}
+			if keys.HostKeyCallback == nil {
+				t.Errorf("sshAuthMethod() expected known_hosts verification")
+			}
+		})
+	}
+}
+
+func TestMigrateAuths(t *testing.T) {
+	viper.Set(StoreKey, MockStoreConfigMap().Map())
+	viper.Set(credentials.CredentialStoreKey, credentials.MockStoreConfigMap().Map())
+	defer viper.Set(StoreKey, nil)
+	defer viper.Set(credentials.CredentialStoreKey, nil)
+	if err := (MockStore{}).WriteRegistry(RepoRegistry{Auths: Auths{
+		trimUrl(DemoHttpsUrl): {Basic: base64.StdEncoding.EncodeToString([]byte(`{"username": "testuser", "password": "testpass"}`))},
+		trimUrl(DemoSshUrl):   {SSH: &SSHAuth{KeyFile: "/key", Passphrase: base64.StdEncoding.EncodeToString([]byte("secret"))}},
+	}}); err != nil {
+		t.Fatal(err)
+	}
+	before, err := AuthMethod(DemoHttpsUrl)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mod, urls, err := MigrateAuths()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(urls) != 2 {
+		t.Errorf("MigrateAuths() migrated %v, want both auths", urls)
+	}
+	if err := UpdateRegistry(mod); err != nil {
+		t.Fatal(err)
+	}
+
+	reg, err := Registry()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for url, auth := range reg.Auths {
+		if auth.Ref == "" || auth.Basic != "" || (auth.SSH != nil && auth.SSH.Passphrase != "") {
+			t.Errorf("MigrateAuths() left secrets in registry for '%s': %+v", url, auth)
+		}
+	}
+	after, err := AuthMethod(DemoHttpsUrl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(before, after) {
+		t.Errorf("AuthMethod() after migration = %v, want %v", after, before)
+	}
+	cred, err := authCredential(reg.Auths[trimUrl(DemoSshUrl)])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cred.Password != "secret" {
+		t.Errorf("MigrateAuths() ssh passphrase = %v, want secret", cred.Password)
+	}
+}
+
+func Test_storeCredential(t *testing.T) {
+	defer viper.Set(StoreKey, nil)
+	defer viper.Set(credentials.CredentialStoreKey, nil)
+	tests := []struct {
+		name            string
+		store           StoreConfigMap
+		credentialStore credentials.StoreConfigMap
+		wantErr         error
+	}{
+		{name: "shared registry", store: EtcdStoreConfigMap([]string{"localhost:2379"}, 100), wantErr: errors.CredentialStoreNotSharedError},
+		{name: "shared registry with configured store", store: EtcdStoreConfigMap([]string{"localhost:2379"}, 100), credentialStore: credentials.MockStoreConfigMap()},
+		{name: "local registry", store: MockStoreConfigMap(), credentialStore: credentials.MockStoreConfigMap()},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			viper.Set(StoreKey, tt.store.Map())
+			viper.Set(credentials.CredentialStoreKey, tt.credentialStore.Map())
+			if _, err := storeCredential(DemoHttpsUrl, credentials.Credential{Password: "pass"}); !goerrors.Is(err, tt.wantErr) {
+				t.Errorf("storeCredential() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestDiscardAuthCredentials(t *testing.T) {
+	viper.Set(credentials.CredentialStoreKey, credentials.MockStoreConfigMap().Map())
+	defer viper.Set(credentials.CredentialStoreKey, nil)
+
+	authMod, err := StoreRepoAuth(DemoHttpsUrl, AuthPair{Username: "user", Password: "pass"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ref := authMod(RepoRegistry{}).Auths[trimUrl(DemoHttpsUrl)].Ref
+	store, err := credentials.GetStoreFromConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Get(ref); err != nil {
+		t.Fatal(err)
+	}
+
+	mods := []RegistryModification{storeAuth(DemoSshUrl, Auth{CredentialHelper: true}), authMod}
+	if err := DiscardAuthCredentials(mods...); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Get(ref); !goerrors.Is(err, errors.CredentialNotFoundError) {
+		t.Errorf("DiscardAuthCredentials() left credential, Get() error = %v", err)
+	}
+}
+
+func Test_tokenAuthMethod(t *testing.T) {
+	tokenFile, err := ioutil.TempFile("", "kable-token")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(tokenFile.Name())
+	if _, err := tokenFile.WriteString("filetoken\n"); err != nil {
+		t.Fatal(err)
+	}
+	tokenFile.Close()
+	os.Setenv("KABLE_TEST_TOKEN", "envtoken")
+	defer os.Unsetenv("KABLE_TEST_TOKEN")
+
+	tests := []struct {
+		name    string
+		auth    TokenAuth
+		want    transport.AuthMethod
+		wantErr bool
+	}{
+		{name: "env basic", auth: TokenAuth{Env: "KABLE_TEST_TOKEN"}, want: &http.BasicAuth{Username: "x-access-token", Password: "envtoken"}},
+		{name: "file bearer", auth: TokenAuth{File: tokenFile.Name(), Style: BearerTokenAuthStyle}, want: &http.TokenAuth{Token: "filetoken"}},
+		{name: "custom user", auth: TokenAuth{Env: "KABLE_TEST_TOKEN", Username: "oauth2"}, want: &http.BasicAuth{Username: "oauth2", Password: "envtoken"}},
+		{name: "empty env", auth: TokenAuth{Env: "KABLE_TEST_TOKEN_UNSET"}, wantErr: true},
+		{name: "no source", auth: TokenAuth{}, wantErr: true},
+		{name: "both sources", auth: TokenAuth{Env: "KABLE_TEST_TOKEN", File: tokenFile.Name()}, wantErr: true},
+		{name: "invalid style", auth: TokenAuth{Env: "KABLE_TEST_TOKEN", Style: "digest"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tokenAuthMethod(tt.auth)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("tokenAuthMethod() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("tokenAuthMethod() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,420 @@
+package repositories
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+
+	"github.com/redradrat/kable/pkg/errors"
+)
+
+const (
+	BundleVersion      = 1
+	BundleManifestName = "bundle.json"
+	BundlesDirName     = "bundles"
+	// bundleFilesPrefix is the directory of the repository content within the bundle archive
+	bundleFilesPrefix = "files/"
+	// bundleRepoDir is the directory of the repository content within an imported bundle
+	bundleRepoDir = "repo"
+)
+
+var BundlesDir = filepath.Join(KableDir, BundlesDirName)
+
+// BundleManifest describes the repository packed into a bundle, along with the checksums of its content
+type BundleManifest struct {
+	Version    int                   `json:"version"`
+	Created    time.Time             `json:"created"`
+	Repository BundleRepository      `json:"repository"`
+	Files      map[string]BundleFile `json:"files"`
+}
+
+// BundleRepository is the registry metadata of an exported repository
+type BundleRepository struct {
+	Name   string `json:"name"`
+	URL    string `json:"url"`
+	Ref    string `json:"ref,omitempty"`
+	Commit string `json:"commit,omitempty"`
+}
+
+// BundleFile is a single file of a bundle. Regular files are recorded with the sha256 digest of their content,
+// symbolic links with their target.
+type BundleFile struct {
+	SHA256     string `json:"sha256,omitempty"`
+	Link       string `json:"link,omitempty"`
+	Executable bool   `json:"executable,omitempty"`
+}
+
+// ExportBundle writes the checkout of the repository at its current commit, together with a manifest of its registry
+// metadata and file checksums, as gzipped tar archive to out. The .git directory is left out.
+func ExportBundle(r Repository, out io.Writer) (*BundleManifest, error) {
+	root, err := r.AbsolutePath()
+	if err != nil {
+		return nil, err
+	}
+	commit, err := r.HeadCommit()
+	if err != nil {
+		return nil, err
+	}
+
+	manifest := BundleManifest{
+		Version:    BundleVersion,
+		Created:    time.Now().UTC().Truncate(time.Second),
+		Repository: BundleRepository{Name: r.Name, URL: r.URL, Ref: r.GitRef, Commit: commit},
+		Files:      map[string]BundleFile{},
+	}
+	err = filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			if info.Name() == git.GitDirName {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		switch {
+		case info.Mode()&os.ModeSymlink != 0:
+			link, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			manifest.Files[filepath.ToSlash(rel)] = BundleFile{Link: filepath.ToSlash(link)}
+		case info.Mode().IsRegular():
+			digest, err := fileDigest(path)
+			if err != nil {
+				return err
+			}
+			manifest.Files[filepath.ToSlash(rel)] = BundleFile{SHA256: digest, Executable: info.Mode()&0111 != 0}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	gw := gzip.NewWriter(out)
+	tw := tar.NewWriter(gw)
+	b, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err := tw.WriteHeader(&tar.Header{Name: BundleManifestName, Mode: 0644, Size: int64(len(b)), ModTime: manifest.Created}); err != nil {
+		return nil, err
+	}
+	if _, err := tw.Write(b); err != nil {
+		return nil, err
+	}
+	for _, name := range manifest.sortedFiles() {
+		if err := writeBundleFile(tw, root, name, manifest.Files[name], manifest.Created); err != nil {
+			return nil, err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return nil, err
+	}
+	if err := gw.Close(); err != nil {
+		return nil, err
+	}
+	return &manifest, nil
+}
+
+func writeBundleFile(tw *tar.Writer, root, name string, file BundleFile, modTime time.Time) error {
+	hdr := &tar.Header{Name: bundleFilesPrefix + name, Mode: 0644, ModTime: modTime}
+	if file.Executable {
+		hdr.Mode = 0755
+	}
+	if file.Link != "" {
+		hdr.Typeflag = tar.TypeSymlink
+		hdr.Linkname = file.Link
+		return tw.WriteHeader(hdr)
+	}
+
+	f, err := os.Open(filepath.Join(root, filepath.FromSlash(name)))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	hdr.Typeflag = tar.TypeReg
+	hdr.Size = info.Size()
+	if err := tw.WriteHeader(hdr); err != nil {
+		return err
+	}
+	_, err = io.Copy(tw, f)
+	return err
+}
+
+// ImportBundle unpacks the bundle read from in into BundlesDir, and returns the modification registering it as local
+// repository. Every file is checked against the checksums of the manifest, so modified, missing or additional files
+// fail the import. The repository is named as in the manifest, unless name is given. An existing repository of that
+// name is only replaced, if it has been imported from a bundle as well.
+func ImportBundle(in io.Reader, name string) (*BundleManifest, RegistryModification, error) {
+	if err := os.MkdirAll(BundlesDir, os.ModePerm); err != nil {
+		return nil, nil, err
+	}
+	tmp, err := ioutil.TempDir(BundlesDir, ".import-")
+	if err != nil {
+		return nil, nil, err
+	}
+	defer os.RemoveAll(tmp)
+
+	manifest, digest, err := extractBundle(in, filepath.Join(tmp, bundleRepoDir))
+	if err != nil {
+		return nil, nil, err
+	}
+	if name == "" {
+		name = manifest.Repository.Name
+	}
+	if !IsValidRepositoryName(name) {
+		return nil, nil, fmt.Errorf("repository name can only be lowercase letters (a-z) and '-'")
+	}
+	existing, err := GetRepository(name)
+	if err == nil && bundleDir(existing) == "" {
+		return nil, nil, fmt.Errorf("%w: '%s'", errors.RepositoryAlreadyExistsError, name)
+	}
+	if err != nil && err != errors.RepositoryUnknownError {
+		return nil, nil, err
+	}
+
+	b, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := ioutil.WriteFile(filepath.Join(tmp, BundleManifestName), b, 0644); err != nil {
+		return nil, nil, err
+	}
+	dest := filepath.Join(BundlesDir, name+"-"+digest[:12])
+	if err := safeDelete(dest); err != nil {
+		return nil, nil, err
+	}
+	if err := os.Rename(tmp, dest); err != nil {
+		return nil, nil, err
+	}
+
+	mod, err := AddRepository(Repository{Name: name, GitRepository: GitRepository{URL: LocalURLScheme + filepath.Join(dest, bundleRepoDir)}})
+	if err != nil {
+		return nil, nil, err
+	}
+	return manifest, mod, nil
+}
+
+// extractBundle unpacks the files of the bundle into root, and returns its manifest along with the digest of the
+// manifest.
+func extractBundle(in io.Reader, root string) (*BundleManifest, string, error) {
+	gr, err := gzip.NewReader(in)
+	if err != nil {
+		return nil, "", fmt.Errorf("%w: %v", errors.BundleInvalidError, err)
+	}
+	defer gr.Close()
+	tr := tar.NewReader(gr)
+
+	hdr, err := tr.Next()
+	if err != nil || hdr.Name != BundleManifestName {
+		return nil, "", fmt.Errorf("%w: expected '%s' first", errors.BundleInvalidError, BundleManifestName)
+	}
+	b, err := ioutil.ReadAll(tr)
+	if err != nil {
+		return nil, "", err
+	}
+	manifest := BundleManifest{}
+	if err := json.Unmarshal(b, &manifest); err != nil {
+		return nil, "", fmt.Errorf("%w: %v", errors.BundleInvalidError, err)
+	}
+	if manifest.Version != BundleVersion {
+		return nil, "", fmt.Errorf("%w: unsupported version %d", errors.BundleInvalidError, manifest.Version)
+	}
+	sum := sha256.Sum256(b)
+	if err := os.MkdirAll(root, os.ModePerm); err != nil {
+		return nil, "", err
+	}
+
+	// Links are only checked lexically, so entries must never be extracted through a link extracted before
+	seen := map[string]bool{}
+	links := map[string]bool{}
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, "", fmt.Errorf("%w: %v", errors.BundleInvalidError, err)
+		}
+		name := strings.TrimPrefix(hdr.Name, bundleFilesPrefix)
+		file, ok := manifest.Files[name]
+		if !ok || name == hdr.Name || seen[name] {
+			return nil, "", fmt.Errorf("%w: unexpected file '%s'", errors.BundleChecksumError, hdr.Name)
+		}
+		if !isSafeBundlePath(name) || isBelowBundleLink(links, name) {
+			return nil, "", fmt.Errorf("%w: path '%s' leaves the bundle", errors.BundleInvalidError, name)
+		}
+		seen[name] = true
+		if err := extractBundleFile(tr, hdr, root, name, file); err != nil {
+			return nil, "", err
+		}
+		if hdr.Typeflag == tar.TypeSymlink {
+			links[path.Clean(name)] = true
+		}
+	}
+	for _, name := range manifest.sortedFiles() {
+		if !seen[name] {
+			return nil, "", fmt.Errorf("%w: missing file '%s'", errors.BundleChecksumError, name)
+		}
+	}
+	return &manifest, hex.EncodeToString(sum[:]), nil
+}
+
+func extractBundleFile(tr *tar.Reader, hdr *tar.Header, root, name string, file BundleFile) error {
+	dest := filepath.Join(root, filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(dest), os.ModePerm); err != nil {
+		return err
+	}
+	within, err := isWithinDir(root, filepath.Dir(dest))
+	if err != nil {
+		return err
+	}
+	if !within {
+		return fmt.Errorf("%w: path '%s' leaves the bundle", errors.BundleInvalidError, name)
+	}
+
+	switch hdr.Typeflag {
+	case tar.TypeSymlink:
+		if hdr.Linkname != file.Link {
+			return fmt.Errorf("%w: link '%s'", errors.BundleChecksumError, name)
+		}
+		if path.IsAbs(file.Link) || !isSafeBundlePath(path.Join(path.Dir(name), file.Link)) {
+			return fmt.Errorf("%w: link '%s' leaves the bundle", errors.BundleInvalidError, name)
+		}
+		return os.Symlink(filepath.FromSlash(file.Link), dest)
+	case tar.TypeReg:
+		mode := os.FileMode(0644)
+		if file.Executable {
+			mode = 0755
+		}
+		f, err := os.OpenFile(dest, os.O_CREATE|os.O_EXCL|os.O_WRONLY, mode)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		h := sha256.New()
+		if _, err := io.Copy(io.MultiWriter(f, h), tr); err != nil {
+			return err
+		}
+		if file.Link != "" || hex.EncodeToString(h.Sum(nil)) != file.SHA256 {
+			return fmt.Errorf("%w: file '%s'", errors.BundleChecksumError, name)
+		}
+		return nil
+	default:
+		return fmt.Errorf("%w: unsupported entry '%s'", errors.BundleInvalidError, hdr.Name)
+	}
+}
+
+// isSafeBundlePath returns whether the slash separated, relative path stays within the bundle
+func isSafeBundlePath(name string) bool {
+	clean := path.Clean(name)
+	return name != "" && !path.IsAbs(name) && clean != "." && clean != ".." && !strings.HasPrefix(clean, "../")
+}
+
+// isBelowBundleLink returns whether any parent directory of the slash separated path is one of the given links
+func isBelowBundleLink(links map[string]bool, name string) bool {
+	for dir := path.Dir(path.Clean(name)); dir != "."; dir = path.Dir(dir) {
+		if links[dir] {
+			return true
+		}
+	}
+	return false
+}
+
+// isWithinDir returns whether dir is located within root, after resolving all symbolic links of both
+func isWithinDir(root, dir string) (bool, error) {
+	realRoot, err := filepath.EvalSymlinks(root)
+	if err != nil {
+		return false, err
+	}
+	realDir, err := filepath.EvalSymlinks(dir)
+	if err != nil {
+		return false, err
+	}
+	rel, err := filepath.Rel(realRoot, realDir)
+	if err != nil {
+		return false, nil
+	}
+	return rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)), nil
+}
+
+func (m BundleManifest) sortedFiles() []string {
+	var names []string
+	for name := range m.Files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// BundleDigest returns the sha256 digest of the bundle file at path, to be compared out of band before importing
+func BundleDigest(path string) (string, error) {
+	return fileDigest(path)
+}
+
+func fileDigest(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// bundleDir returns the directory the repository has been imported to from a bundle, or an empty string if it is not
+// an imported bundle.
+func bundleDir(r Repository) string {
+	if !r.IsLocal() {
+		return ""
+	}
+	dir := filepath.Dir(filepath.FromSlash(strings.TrimPrefix(r.URL, LocalURLScheme)))
+	if filepath.Dir(dir) != filepath.Clean(BundlesDir) {
+		return ""
+	}
+	return dir
+}
+
+// bundleCommit returns the commit an imported bundle has been exported at, or an empty string if the repository is
+// not an imported bundle.
+func bundleCommit(r Repository) string {
+	dir := bundleDir(r)
+	if dir == "" {
+		return ""
+	}
+	b, err := ioutil.ReadFile(filepath.Join(dir, BundleManifestName))
+	if err != nil {
+		return ""
+	}
+	manifest := BundleManifest{}
+	if err := json.Unmarshal(b, &manifest); err != nil {
+		return ""
+	}
+	return manifest.Repository.Commit
+}
@@ -0,0 +1,258 @@
+package repositories
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	goerrors "errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/viper"
+
+	"github.com/redradrat/kable/pkg/errors"
+)
+
+func TestBundle_roundtrip(t *testing.T) {
+	viper.Set(StoreKey, MockStoreConfigMap().Map())
+	defer viper.Set(StoreKey, nil)
+	defer delete(mockStoreBackend, "registry")
+	dir, err := ioutil.TempDir("", "kable-bundle")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	origBundlesDir := BundlesDir
+	BundlesDir = filepath.Join(dir, "bundles")
+	defer func() { BundlesDir = origBundlesDir }()
+
+	src := filepath.Join(dir, "src")
+	files := map[string]string{
+		RepoIndexFileName:           `{"version": 1, "concepts": ["a"]}`,
+		"a/concept.json":            `{}`,
+		"a/vendor/github.com/x/lib": "lib",
+		".git/config":               "not exported",
+	}
+	for name, content := range files {
+		path := filepath.Join(src, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Symlink("github.com/x", filepath.Join(src, "a", "vendor", "x")); err != nil {
+		t.Fatal(err)
+	}
+
+	bundle := &bytes.Buffer{}
+	manifest, err := ExportBundle(Repository{Name: "src", GitRepository: GitRepository{URL: LocalURLScheme + src}}, bundle)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(manifest.Files) != 4 || manifest.Files["a/vendor/x"].Link != "github.com/x" {
+		t.Fatalf("ExportBundle() files = %v, want 3 files and a link", manifest.Files)
+	}
+
+	// Tampering with the content of any file fails the import
+	tampered := rewriteBundle(t, bundle.Bytes(), "files/a/concept.json", []byte(`{"type": "evil"}`))
+	if _, _, err := ImportBundle(bytes.NewReader(tampered), ""); !goerrors.Is(err, errors.BundleChecksumError) {
+		t.Errorf("ImportBundle() tampered error = %v, want %v", err, errors.BundleChecksumError)
+	}
+
+	_, mod, err := ImportBundle(bytes.NewReader(bundle.Bytes()), "imported")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := UpdateRegistry(mod); err != nil {
+		t.Fatal(err)
+	}
+	r, err := GetRepository("imported")
+	if err != nil {
+		t.Fatal(err)
+	}
+	path, err := r.AbsolutePath()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b, err := ioutil.ReadFile(filepath.Join(path, "a", "vendor", "x", "lib")); err != nil || string(b) != "lib" {
+		t.Errorf("ImportBundle() expected linked file to be readable: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(path, ".git")); !os.IsNotExist(err) {
+		t.Errorf("ImportBundle() expected .git not to be part of the bundle")
+	}
+
+	// Only repositories imported from a bundle are replaced
+	if _, _, err := ImportBundle(bytes.NewReader(bundle.Bytes()), "imported"); err != nil {
+		t.Errorf("ImportBundle() reimport error = %v", err)
+	}
+	mod, err = AddRepository(Repository{Name: "plain", GitRepository: GitRepository{URL: LocalURLScheme + src}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := UpdateRegistry(mod); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := ImportBundle(bytes.NewReader(bundle.Bytes()), "plain"); !goerrors.Is(err, errors.RepositoryAlreadyExistsError) {
+		t.Errorf("ImportBundle() error = %v, want %v", err, errors.RepositoryAlreadyExistsError)
+	}
+}
+
+func TestImportBundle_linkEscape(t *testing.T) {
+	viper.Set(StoreKey, MockStoreConfigMap().Map())
+	defer viper.Set(StoreKey, nil)
+	dir, err := ioutil.TempDir("", "kable-bundle")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	origBundlesDir := BundlesDir
+	BundlesDir = filepath.Join(dir, "bundles")
+	defer func() { BundlesDir = origBundlesDir }()
+
+	// Both links pass the lexical check, but extracting 'm' through 'l' points it outside of the bundle
+	deep := "a1/a2/a3/a4/a5/a6/a7/a8"
+	content := []byte("escaped")
+	sum := sha256.Sum256(content)
+	manifest := BundleManifest{Version: BundleVersion, Repository: BundleRepository{Name: "evil"}, Files: map[string]BundleFile{
+		deep + "/l":       {Link: "../../../../../../.."},
+		deep + "/l/m":     {Link: "../../../../ESCAPED"},
+		deep + "/l/m/out": {SHA256: hex.EncodeToString(sum[:])},
+	}}
+	b, err := json.Marshal(manifest)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	bundle := &bytes.Buffer{}
+	gw := gzip.NewWriter(bundle)
+	tw := tar.NewWriter(gw)
+	for _, entry := range []struct {
+		hdr     tar.Header
+		content []byte
+	}{
+		{hdr: tar.Header{Name: BundleManifestName, Typeflag: tar.TypeReg, Mode: 0644}, content: b},
+		{hdr: tar.Header{Name: bundleFilesPrefix + deep + "/l", Typeflag: tar.TypeSymlink, Linkname: "../../../../../../..", Mode: 0777}},
+		{hdr: tar.Header{Name: bundleFilesPrefix + deep + "/l/m", Typeflag: tar.TypeSymlink, Linkname: "../../../../ESCAPED", Mode: 0777}},
+		{hdr: tar.Header{Name: bundleFilesPrefix + deep + "/l/m/out", Typeflag: tar.TypeReg, Mode: 0644}, content: content},
+	} {
+		entry.hdr.Size = int64(len(entry.content))
+		if err := tw.WriteHeader(&entry.hdr); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write(entry.content); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	escaped := filepath.Join(dir, "ESCAPED")
+	if err := os.Mkdir(escaped, os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := ImportBundle(bundle, ""); !goerrors.Is(err, errors.BundleInvalidError) {
+		t.Errorf("ImportBundle() error = %v, want %v", err, errors.BundleInvalidError)
+	}
+	if _, err := os.Stat(filepath.Join(escaped, "out")); !os.IsNotExist(err) {
+		t.Errorf("ImportBundle() wrote outside of the bundle")
+	}
+}
+
+// rewriteBundle returns the given bundle, with the content of the entry name replaced
+func rewriteBundle(t *testing.T, bundle []byte, name string, content []byte) []byte {
+	gr, err := gzip.NewReader(bytes.NewReader(bundle))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tr := tar.NewReader(gr)
+	out := &bytes.Buffer{}
+	gw := gzip.NewWriter(out)
+	tw := tar.NewWriter(gw)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := ioutil.ReadAll(tr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if hdr.Name == name {
+			b = content
+			hdr.Size = int64(len(b))
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write(b); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return out.Bytes()
+}
+
+func Test_isSafeBundlePath(t *testing.T) {
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{path: "a/b", want: true},
+		{path: "a/../b", want: true},
+		{path: "../a"},
+		{path: "a/../../b"},
+		{path: "/etc/passwd"},
+		{path: "."},
+		{path: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			if got := isSafeBundlePath(tt.path); got != tt.want {
+				t.Errorf("isSafeBundlePath() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_isBelowBundleLink(t *testing.T) {
+	links := map[string]bool{"a/l": true}
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{path: "a/l"},
+		{path: "a/b"},
+		{path: "a/lib"},
+		{path: "a/l/m", want: true},
+		{path: "a/l/m/out", want: true},
+		{path: "a/./l/m", want: true},
+		{path: "a/b/../l/m", want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			if got := isBelowBundleLink(links, tt.path); got != tt.want {
+				t.Errorf("isBelowBundleLink() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
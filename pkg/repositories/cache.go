@@ -0,0 +1,216 @@
+package repositories
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	"github.com/spf13/viper"
+)
+
+const (
+	// OfflineKey is the setting forbidding any network access, so repositories are only read from CacheDir
+	OfflineKey = "offline"
+	// lastUpdateFile is touched within the .git directory of a checkout, whenever it has been cloned or pulled
+	lastUpdateFile = "kable-updated"
+)
+
+var defaultPorts = map[string]int{
+	"ssh":   22,
+	"http":  80,
+	"https": 443,
+	"git":   9418,
+}
+
+// normalizeURL returns a canonical form of the given git URL, so trivially different spellings of the same remote
+// (host case, '.git' suffix, trailing slashes, user, default port) map to the same cache entry.
+func normalizeURL(url string) string {
+	url = strings.TrimSpace(url)
+	ep, err := transport.NewEndpoint(url)
+	if err != nil {
+		return trimUrl(strings.TrimRight(url, "/"))
+	}
+
+	p := strings.TrimSuffix(strings.TrimRight(ep.Path, "/"), ".git")
+	if ep.Protocol == "file" {
+		if abs, err := filepath.Abs(p); err == nil {
+			p = abs
+		}
+		return "file://" + filepath.ToSlash(p)
+	}
+
+	host := strings.ToLower(ep.Host)
+	if ep.Port != 0 && ep.Port != defaultPorts[ep.Protocol] {
+		host = fmt.Sprintf("%s:%d", host, ep.Port)
+	}
+	return fmt.Sprintf("%s://%s/%s", ep.Protocol, host, strings.TrimLeft(p, "/"))
+}
+
+// computePath returns the cache directory of the checkout of url at ref. The directory name is prefixed with the last
+// URL path segment for readability, and keyed by a hash of the normalized URL and ref, so distinct remotes with the
+// same name, and distinct refs of the same remote, never share a checkout.
+func computePath(url, ref string) string {
+	ref = strings.TrimSpace(ref)
+	if ref == "" {
+		ref = masterGitRef
+	}
+	normalized := normalizeURL(url)
+	sum := sha256.Sum256([]byte(normalized + "\n" + ref))
+	name := path.Base(normalized)
+	return filepath.Join(CacheDir, fmt.Sprintf("%s-%s", name, hex.EncodeToString(sum[:])[:16]))
+}
+
+// legacyCachePath returns the cache directory used by older kable versions, which was only named by the last URL
+// path segment.
+func legacyCachePath(url string) string {
+	a := strings.Split(trimUrl(url), "/")
+	return filepath.Join(CacheDir, a[len(a)-1])
+}
+
+// cachePath returns the cache directory of the repository, after moving a checkout of the legacy layout there.
+func (r Repository) cachePath() (string, error) {
+	path := computePath(r.URL, r.GitRef)
+	if err := migrateLegacyCache(r, path); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// migrateLegacyCache moves the legacy checkout of the repository to path, if it is a checkout of the same remote and
+// branch. Anything else is left in place to be removed by TidyCache, and the repository is cloned anew.
+func migrateLegacyCache(r Repository, path string) error {
+	if _, err := os.Stat(path); err == nil {
+		return nil
+	}
+	legacy := legacyCachePath(r.URL)
+	repo, err := git.PlainOpen(legacy)
+	if err != nil {
+		return nil
+	}
+
+	remote, err := repo.Remote(git.DefaultRemoteName)
+	if err != nil || len(remote.Config().URLs) == 0 || normalizeURL(remote.Config().URLs[0]) != normalizeURL(r.URL) {
+		return nil
+	}
+	ref, err := ParseGitRef(r.GitRef)
+	if err != nil || ref.Kind != BranchGitRef {
+		return nil
+	}
+	head, err := repo.Head()
+	if err != nil || head.Name().String() != ref.Value {
+		return nil
+	}
+
+	if err := os.MkdirAll(CacheDir, os.ModePerm); err != nil {
+		return err
+	}
+	return os.Rename(legacy, path)
+}
+
+// TidyCache removes all cached checkouts that don't belong to a registered repository (and ref), as well as imported
+// bundles no longer registered. Checkouts of the legacy layout are migrated first, if possible.
+func TidyCache() error {
+	repos, err := ListRepositories()
+	if err != nil {
+		return err
+	}
+	keep := map[string]bool{}
+	keepBundles := map[string]bool{}
+	for _, repo := range repos {
+		if repo.IsLocal() {
+			if dir := bundleDir(repo); dir != "" {
+				keepBundles[filepath.Base(dir)] = true
+			}
+			continue
+		}
+		path, err := repo.cachePath()
+		if err != nil {
+			return err
+		}
+		keep[filepath.Base(path)] = true
+	}
+
+	if err := tidyDir(CacheDir, keep); err != nil {
+		return err
+	}
+	return tidyDir(BundlesDir, keepBundles)
+}
+
+// tidyDir removes everything within dir, that is not kept
+func tidyDir(dir string, keep map[string]bool) error {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	for _, file := range files {
+		if keep[file.Name()] {
+			continue
+		}
+		if err := os.RemoveAll(filepath.Join(dir, file.Name())); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// IsOffline returns whether network access is forbidden by the offline setting
+func IsOffline() bool {
+	return viper.GetBool(OfflineKey)
+}
+
+// markUpdated records the current time as last update of the checkout at path
+func markUpdated(path string) error {
+	return ioutil.WriteFile(filepath.Join(path, git.GitDirName, lastUpdateFile), nil, 0644)
+}
+
+// CacheStatus describes the cached checkout of a repository
+type CacheStatus struct {
+	Repository Repository
+	Path       string
+	Cached     bool
+	Commit     string
+	Tags       []string
+	// LastUpdate is the time the checkout has last been cloned or pulled, if known
+	LastUpdate time.Time
+}
+
+// CacheStatus returns the status of the cached checkout of the repository, without touching the network. Local
+// repositories are always cached.
+func (r Repository) CacheStatus() (*CacheStatus, error) {
+	status := &CacheStatus{Repository: r}
+	var err error
+	if r.IsLocal() {
+		status.Path, err = r.localPath()
+		status.Cached = err == nil
+	} else {
+		status.Path, err = r.cachePath()
+		if err != nil {
+			return nil, err
+		}
+		if info, err := os.Stat(filepath.Join(status.Path, git.GitDirName, lastUpdateFile)); err == nil {
+			status.LastUpdate = info.ModTime()
+		}
+		_, err = git.PlainOpen(status.Path)
+		status.Cached = err == nil
+	}
+	if !status.Cached {
+		return status, nil
+	}
+
+	status.Commit, status.Tags, err = r.CheckedOutRef()
+	if err != nil {
+		return nil, err
+	}
+	return status, nil
+}
@@ -0,0 +1,133 @@
+package repositories
+
+import (
+	goerrors "errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/spf13/viper"
+
+	"github.com/redradrat/kable/pkg/errors"
+)
+
+func Test_normalizeURL(t *testing.T) {
+	tests := []struct {
+		url  string
+		want string
+	}{
+		{url: "https://github.com/redradrat/kable.git", want: "https://github.com/redradrat/kable"},
+		{url: "https://user@GitHub.com:443/redradrat/kable/", want: "https://github.com/redradrat/kable"},
+		{url: "https://git.example.com:8443/kable", want: "https://git.example.com:8443/kable"},
+		{url: "git@github.com:redradrat/kable.git", want: "ssh://github.com/redradrat/kable"},
+		{url: "ssh://git@github.com/redradrat/kable", want: "ssh://github.com/redradrat/kable"},
+		{url: "/srv/git/kable.git", want: "file:///srv/git/kable"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			if got := normalizeURL(tt.url); got != tt.want {
+				t.Errorf("normalizeURL() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTidyCache_migrate(t *testing.T) {
+	viper.Set(StoreKey, MockStoreConfigMap().Map())
+	defer viper.Set(StoreKey, nil)
+	dir, err := ioutil.TempDir("", "kable-cache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	origCacheDir := CacheDir
+	CacheDir = filepath.Join(dir, "cache")
+	defer func() { CacheDir = origCacheDir }()
+
+	src := filepath.Join(dir, "concepts")
+	upstream, err := git.PlainInit(src, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	commitAndTag(t, upstream, src, "v1.0.0")
+
+	// A checkout of the legacy layout, and a stale entry of an unregistered repository
+	if _, err := git.PlainClone(legacyCachePath(src), false, &git.CloneOptions{URL: src}); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(CacheDir, "stale"), os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+
+	repo := Repository{Name: "concepts", GitRepository: GitRepository{URL: src, GitRef: masterGitRef}}
+	add, err := AddRepository(repo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := UpdateRegistry(add); err != nil {
+		t.Fatal(err)
+	}
+	defer delete(mockStoreBackend, "registry")
+
+	if err := TidyCache(); err != nil {
+		t.Fatal(err)
+	}
+	entries, err := ioutil.ReadDir(CacheDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || filepath.Join(CacheDir, entries[0].Name()) != computePath(src, masterGitRef) {
+		t.Errorf("TidyCache() left %v, want only the migrated checkout", entries)
+	}
+	if _, err := git.PlainOpen(computePath(src, masterGitRef)); err != nil {
+		t.Errorf("TidyCache() expected migrated checkout: %v", err)
+	}
+}
+
+func Test_maybeClone_offline(t *testing.T) {
+	viper.Set(StoreKey, MockStoreConfigMap().Map())
+	defer viper.Set(StoreKey, nil)
+	dir, err := ioutil.TempDir("", "kable-offline")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	src := filepath.Join(dir, "concepts")
+	upstream, err := git.PlainInit(src, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	commit := commitAndTag(t, upstream, src, "v1.0.0")
+	r := Repository{Name: "concepts", GitRepository: GitRepository{URL: src, GitRef: masterGitRef}}
+	cached := filepath.Join(dir, "cached")
+	if err := maybeClone(r, cached, false); err != nil {
+		t.Fatal(err)
+	}
+
+	viper.Set(OfflineKey, true)
+	defer viper.Set(OfflineKey, nil)
+	tests := []struct {
+		name    string
+		path    string
+		pull    bool
+		wantErr error
+	}{
+		{name: "cached", path: cached},
+		{name: "pull", path: cached, pull: true, wantErr: errors.OfflineError},
+		{name: "missing", path: filepath.Join(dir, "missing"), wantErr: errors.RepositoryCacheMissingError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := maybeClone(r, tt.path, tt.pull)
+			if (tt.wantErr == nil && err != nil) || !goerrors.Is(err, tt.wantErr) {
+				t.Errorf("maybeClone() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+	if _, err := os.Stat(filepath.Join(dir, "missing")); !os.IsNotExist(err) {
+		t.Errorf("maybeClone() created a checkout in offline mode")
+	}
+	assertHead(t, cached, commit)
+}
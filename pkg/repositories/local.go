@@ -0,0 +1,63 @@
+package repositories
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+
+	"github.com/redradrat/kable/pkg/errors"
+)
+
+// LocalURLScheme marks repositories, that are read straight from a local directory instead of being cloned
+const LocalURLScheme = "file://"
+
+// LocalURL returns the repository URL for the local directory at path.
+func LocalURL(path string) (string, error) {
+	abs, err := filepath.Abs(strings.TrimPrefix(path, LocalURLScheme))
+	if err != nil {
+		return "", err
+	}
+	return LocalURLScheme + filepath.ToSlash(abs), nil
+}
+
+// IsLocalURL returns whether the given repository URL refers to a local directory
+func IsLocalURL(url string) bool {
+	return strings.HasPrefix(url, LocalURLScheme)
+}
+
+// IsRemoteURL returns whether the given git URL refers to a remote, rather than to a directory or path on this host
+func IsRemoteURL(url string) bool {
+	if IsLocalURL(url) {
+		return false
+	}
+	ep, err := transport.NewEndpoint(url)
+	return err == nil && ep.Protocol != "file"
+}
+
+// IsLocal returns whether the repository is a local directory
+func (r Repository) IsLocal() bool {
+	return IsLocalURL(r.URL)
+}
+
+// localPath returns the directory of a local repository, after checking it exists
+func (r Repository) localPath() (string, error) {
+	path := filepath.FromSlash(strings.TrimPrefix(r.URL, LocalURLScheme))
+	info, err := os.Stat(path)
+	if err != nil || !info.IsDir() {
+		return "", fmt.Errorf("%w: %s", errors.NotDirError, path)
+	}
+	return path, nil
+}
+
+// openLocal opens the git repository the local directory is part of, or returns nil if it is not part of any.
+func openLocal(path string) *git.Repository {
+	repo, err := git.PlainOpenWithOptions(path, &git.PlainOpenOptions{DetectDotGit: true})
+	if err != nil {
+		return nil
+	}
+	return repo
+}
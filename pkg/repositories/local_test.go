@@ -0,0 +1,84 @@
+package repositories
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/spf13/viper"
+)
+
+func TestLocalRepository(t *testing.T) {
+	viper.Set(StoreKey, MockStoreConfigMap().Map())
+	defer viper.Set(StoreKey, nil)
+	defer delete(mockStoreBackend, "registry")
+	dir, err := ioutil.TempDir("", "kable-local")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	plain := filepath.Join(dir, "plain")
+	if err := os.MkdirAll(plain, os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	versioned := filepath.Join(dir, "versioned")
+	gitRepo, err := git.PlainInit(versioned, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	commit := commitAndTag(t, gitRepo, versioned, "v1.0.0")
+
+	tests := []struct {
+		name       string
+		path       string
+		wantCommit string
+		wantErr    bool
+	}{
+		{name: "plain", path: plain},
+		{name: "git", path: versioned, wantCommit: commit.String()},
+		{name: "missing", path: filepath.Join(dir, "missing"), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mod, err := AddRepository(Repository{Name: tt.name, GitRepository: GitRepository{URL: LocalURLScheme + tt.path, GitRef: "refs/heads/x"}})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("AddRepository() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if err := UpdateRegistry(mod); err != nil {
+				t.Fatal(err)
+			}
+			r, err := GetRepository(tt.name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if r.GitRef != "" {
+				t.Errorf("AddRepository() kept ref %s for local repository", r.GitRef)
+			}
+			path, err := r.AbsolutePath()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if path != tt.path {
+				t.Errorf("AbsolutePath() = %v, want %v", path, tt.path)
+			}
+			got, err := r.HeadCommit()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.wantCommit {
+				t.Errorf("HeadCommit() = %v, want %v", got, tt.wantCommit)
+			}
+		})
+	}
+
+	// Updating never touches local repositories
+	if err := UpdateRepositories(); err != nil {
+		t.Errorf("UpdateRepositories() error = %v", err)
+	}
+}
@@ -0,0 +1,216 @@
+package repositories
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	"github.com/go-git/go-git/v5/storage/memory"
+
+	"github.com/redradrat/kable/pkg/errors"
+)
+
+type GitRefKind string
+
+const (
+	BranchGitRef GitRefKind = "branch"
+	TagGitRef    GitRefKind = "tag"
+	CommitGitRef GitRefKind = "commit"
+	RangeGitRef  GitRefKind = "range"
+)
+
+var isCommitSHA = regexp.MustCompile("^[0-9a-f]{40}$").MatchString
+
+// GitRef is a parsed GitRepository.GitRef. Supported are branches ('refs/heads/main'), tags ('refs/tags/v1.2.0'),
+// full commit SHAs, and semver ranges of tags ('~1.2', '>= 1.0, < 2').
+type GitRef struct {
+	Kind  GitRefKind
+	Value string
+	// Constraint is set for RangeGitRef
+	Constraint *semver.Constraints
+}
+
+func ParseGitRef(ref string) (*GitRef, error) {
+	switch {
+	case ref == "":
+		return &GitRef{Kind: BranchGitRef, Value: masterGitRef}, nil
+	case strings.HasPrefix(ref, "refs/heads/"):
+		return &GitRef{Kind: BranchGitRef, Value: ref}, nil
+	case strings.HasPrefix(ref, "refs/tags/"):
+		return &GitRef{Kind: TagGitRef, Value: ref}, nil
+	case isCommitSHA(ref):
+		return &GitRef{Kind: CommitGitRef, Value: ref}, nil
+	}
+	c, err := semver.NewConstraint(ref)
+	if err != nil {
+		return nil, fmt.Errorf("%w: '%s' (expected a branch or tag ref, a commit SHA, or a semver range)", errors.InvalidGitRefError, ref)
+	}
+	return &GitRef{Kind: RangeGitRef, Value: ref, Constraint: c}, nil
+}
+
+// ResolveTagRange returns the tag ref of the newest tag matching the constraint. Tags not following semver are ignored.
+func ResolveTagRange(c *semver.Constraints, tags []string) (string, error) {
+	var newest *semver.Version
+	var newestTag string
+	for _, tag := range tags {
+		name := strings.TrimPrefix(tag, "refs/tags/")
+		v, err := semver.NewVersion(name)
+		if err != nil || !c.Check(v) {
+			continue
+		}
+		if newest == nil || v.GreaterThan(newest) {
+			newest, newestTag = v, tag
+		}
+	}
+	if newest == nil {
+		return "", fmt.Errorf("%w: no tag matches '%s'", errors.InvalidGitRefError, c)
+	}
+	return newestTag, nil
+}
+
+// remoteTags lists the tag refs of the given remote repository
+func remoteTags(url string, auth transport.AuthMethod) ([]string, error) {
+	remote := git.NewRemote(memory.NewStorage(), &config.RemoteConfig{Name: git.DefaultRemoteName, URLs: []string{url}})
+	refs, err := remote.List(&git.ListOptions{Auth: auth})
+	if err != nil {
+		return nil, err
+	}
+	var tags []string
+	for _, ref := range refs {
+		if ref.Name().IsTag() {
+			tags = append(tags, ref.Name().String())
+		}
+	}
+	return tags, nil
+}
+
+// cloneRef clones the repository at the given ref. Branches and tags are cloned on their own, while commits need all
+// branches and tags to be found.
+func cloneRef(r Repository, ref *GitRef, path string, auth transport.AuthMethod, progress io.Writer) error {
+	if ref.Kind == RangeGitRef {
+		tags, err := remoteTags(r.URL, auth)
+		if err != nil {
+			return err
+		}
+		tag, err := ResolveTagRange(ref.Constraint, tags)
+		if err != nil {
+			return err
+		}
+		ref = &GitRef{Kind: TagGitRef, Value: tag}
+	}
+
+	opts := &git.CloneOptions{URL: r.URL, Auth: auth, Progress: progress}
+	if ref.Kind != CommitGitRef {
+		opts.ReferenceName = plumbing.ReferenceName(ref.Value)
+		opts.SingleBranch = true
+	}
+	repo, err := git.PlainClone(path, false, opts)
+	if err != nil {
+		return err
+	}
+	if ref.Kind == CommitGitRef {
+		return checkoutCommit(repo, plumbing.NewHash(ref.Value))
+	}
+	return nil
+}
+
+// updateRef fetches all branches and tags, and checks out the commit the given (non-branch) ref resolves to now
+func updateRef(repo *git.Repository, ref *GitRef, auth transport.AuthMethod, progress io.Writer) error {
+	if err := repo.Fetch(&git.FetchOptions{
+		RemoteName: git.DefaultRemoteName,
+		RefSpecs: []config.RefSpec{
+			"+refs/heads/*:refs/remotes/origin/*",
+			"+refs/tags/*:refs/tags/*",
+		},
+		Auth:     auth,
+		Progress: progress,
+	}); err != nil && err != git.NoErrAlreadyUpToDate {
+		return err
+	}
+
+	var target plumbing.Hash
+	switch ref.Kind {
+	case CommitGitRef:
+		target = plumbing.NewHash(ref.Value)
+	case TagGitRef, RangeGitRef:
+		tag := ref.Value
+		if ref.Kind == RangeGitRef {
+			tags, err := localTags(repo)
+			if err != nil {
+				return err
+			}
+			if tag, err = ResolveTagRange(ref.Constraint, tags); err != nil {
+				return err
+			}
+		}
+		r, err := repo.Reference(plumbing.ReferenceName(tag), true)
+		if err != nil {
+			return fmt.Errorf("unable to resolve '%s': %w", tag, err)
+		}
+		target = r.Hash()
+	}
+	return checkoutCommit(repo, target)
+}
+
+func localTags(repo *git.Repository) ([]string, error) {
+	iter, err := repo.Tags()
+	if err != nil {
+		return nil, err
+	}
+	var tags []string
+	err = iter.ForEach(func(ref *plumbing.Reference) error {
+		tags = append(tags, ref.Name().String())
+		return nil
+	})
+	return tags, err
+}
+
+// checkoutCommit checks out the commit of the given hash, which may also be an annotated tag
+func checkoutCommit(repo *git.Repository, hash plumbing.Hash) error {
+	commit, err := peelToCommit(repo, hash)
+	if err != nil {
+		return fmt.Errorf("commit '%s' not found in any branch or tag: %w", hash, err)
+	}
+	wt, err := repo.Worktree()
+	if err != nil {
+		return err
+	}
+	return wt.Checkout(&git.CheckoutOptions{Hash: commit.Hash, Force: true})
+}
+
+func peelToCommit(repo *git.Repository, hash plumbing.Hash) (*object.Commit, error) {
+	if tag, err := repo.TagObject(hash); err == nil {
+		return tag.Commit()
+	}
+	return repo.CommitObject(hash)
+}
+
+// headTags returns the tags pointing at the checked out commit of the given repository
+func headTags(repo *git.Repository) ([]string, error) {
+	head, err := repo.Head()
+	if err != nil {
+		return nil, err
+	}
+	tags, err := localTags(repo)
+	if err != nil {
+		return nil, err
+	}
+	var out []string
+	for _, tag := range tags {
+		ref, err := repo.Reference(plumbing.ReferenceName(tag), true)
+		if err != nil {
+			continue
+		}
+		if commit, err := peelToCommit(repo, ref.Hash()); err == nil && commit.Hash == head.Hash() {
+			out = append(out, strings.TrimPrefix(tag, "refs/tags/"))
+		}
+	}
+	return out, nil
+}
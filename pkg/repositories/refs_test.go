@@ -0,0 +1,156 @@
+package repositories
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/spf13/viper"
+)
+
+func TestParseGitRef(t *testing.T) {
+	tests := []struct {
+		ref      string
+		wantKind GitRefKind
+		wantErr  bool
+	}{
+		{ref: "", wantKind: BranchGitRef},
+		{ref: "refs/heads/main", wantKind: BranchGitRef},
+		{ref: "refs/tags/v1.2.0", wantKind: TagGitRef},
+		{ref: "5d1a0b7c2f3e4a6b8c9d0e1f2a3b4c5d6e7f8a9b", wantKind: CommitGitRef},
+		{ref: "~1.2", wantKind: RangeGitRef},
+		{ref: ">= 1.0, < 2", wantKind: RangeGitRef},
+		{ref: "main", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.ref, func(t *testing.T) {
+			got, err := ParseGitRef(tt.ref)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseGitRef() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && got.Kind != tt.wantKind {
+				t.Errorf("ParseGitRef() kind = %v, want %v", got.Kind, tt.wantKind)
+			}
+		})
+	}
+}
+
+func TestResolveTagRange(t *testing.T) {
+	tags := []string{"refs/tags/v1.0.0", "refs/tags/v1.2.0", "refs/tags/v1.2.3", "refs/tags/v1.3.0-rc.1", "refs/tags/v2.0.0", "refs/tags/latest"}
+	tests := []struct {
+		constraint string
+		want       string
+		wantErr    bool
+	}{
+		{constraint: "~1.2", want: "refs/tags/v1.2.3"},
+		{constraint: "^1", want: "refs/tags/v1.2.3"},
+		{constraint: ">= 1.0", want: "refs/tags/v2.0.0"},
+		{constraint: "~3", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.constraint, func(t *testing.T) {
+			c, err := semver.NewConstraint(tt.constraint)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := ResolveTagRange(c, tags)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ResolveTagRange() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ResolveTagRange() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// commitAndTag creates a commit in the worktree of repo, and tags it with an annotated tag
+func commitAndTag(t *testing.T, repo *git.Repository, dir, tag string) plumbing.Hash {
+	wt, err := repo.Worktree()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, RepoIndexFileName), []byte(`{"version": 1, "concepts": ["`+tag+`"]}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := wt.Add(RepoIndexFileName); err != nil {
+		t.Fatal(err)
+	}
+	sig := &object.Signature{Name: "test", Email: "test@kab.le", When: time.Now()}
+	hash, err := wt.Commit(tag, &git.CommitOptions{Author: sig})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repo.CreateTag(tag, hash, &git.CreateTagOptions{Tagger: sig, Message: tag}); err != nil {
+		t.Fatal(err)
+	}
+	return hash
+}
+
+func Test_maybeClone_refs(t *testing.T) {
+	viper.Set(StoreKey, MockStoreConfigMap().Map())
+	defer viper.Set(StoreKey, nil)
+	dir, err := ioutil.TempDir("", "kable-refs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	src := filepath.Join(dir, "src")
+	upstream, err := git.PlainInit(src, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	v100 := commitAndTag(t, upstream, src, "v1.0.0")
+	v123 := commitAndTag(t, upstream, src, "v1.2.3")
+	v200 := commitAndTag(t, upstream, src, "v2.0.0")
+
+	tests := []struct {
+		name string
+		ref  string
+		want plumbing.Hash
+	}{
+		{name: "range", ref: "~1.2", want: v123},
+		{name: "tag", ref: "refs/tags/v2.0.0", want: v200},
+		{name: "commit", ref: v100.String(), want: v100},
+		{name: "branch", ref: masterGitRef, want: v200},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(dir, tt.name)
+			r := Repository{GitRepository: GitRepository{URL: src, GitRef: tt.ref}}
+			if err := maybeClone(r, path, false); err != nil {
+				t.Fatal(err)
+			}
+			assertHead(t, path, tt.want)
+		})
+	}
+
+	// Updating a range moves on to the newest matching tag
+	v124 := commitAndTag(t, upstream, src, "v1.2.4")
+	r := Repository{GitRepository: GitRepository{URL: src, GitRef: "~1.2"}}
+	if err := maybeClone(r, filepath.Join(dir, "range"), true); err != nil {
+		t.Fatal(err)
+	}
+	assertHead(t, filepath.Join(dir, "range"), v124)
+}
+
+func assertHead(t *testing.T, path string, want plumbing.Hash) {
+	repo, err := git.PlainOpen(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	head, err := repo.Head()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if head.Hash() != want {
+		t.Errorf("maybeClone() checked out %s, want %s", head.Hash(), want)
+	}
+}
@@ -1,18 +1,21 @@
 package repositories
 
 import (
-	"encoding/base64"
 	"encoding/json"
+	goerrors "errors"
 	"fmt"
+	"io"
 	"io/ioutil"
+	"math/rand"
 	"os"
 	"path/filepath"
 	"regexp"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/go-git/go-git/v5/plumbing"
-	"github.com/go-git/go-git/v5/plumbing/transport/http"
+	"github.com/go-git/go-git/v5/plumbing/transport"
 
 	"github.com/go-git/go-git/v5"
 
@@ -26,6 +29,10 @@ const (
 	CacheDirName              = "cache"
 	masterGitRef              = "refs/heads/master"
 	RepositoryIdentifierRegex = "^([a-z\\-]+)$"
+	// RegistryUpdateAttempts is how often a registry update is attempted, when it conflicts with concurrent updates
+	RegistryUpdateAttempts = 10
+	// registryUpdateBackoff is the maximum backoff in milliseconds per failed attempt
+	registryUpdateBackoff = 20
 )
 
 var IsValidRepositoryName = regexp.MustCompile(RepositoryIdentifierRegex).MatchString
@@ -84,11 +91,29 @@ func AddRepository(repo Repository) (RegistryModification, error) {
 	if !IsValidRepositoryName(repo.Name) {
 		return nil, fmt.Errorf("repository name can only be lowercase letters (a-z) and '-'")
 	}
+	if repo.Verification != nil {
+		if err := repo.Verification.Validate(); err != nil {
+			return nil, err
+		}
+	}
+	if repo.IsLocal() {
+		url, err := LocalURL(repo.URL)
+		if err != nil {
+			return nil, err
+		}
+		repo.URL = url
+		repo.GitRef = ""
+		if _, err := repo.localPath(); err != nil {
+			return nil, err
+		}
+	} else if _, err := ParseGitRef(repo.GitRef); err != nil {
+		return nil, err
+	}
 	addrepofunc := func(registry RepoRegistry) RepoRegistry {
 		if registry.Repositories == nil {
 			registry.Repositories = Repositories{}
 		}
-		if repo.GitRef == "" {
+		if repo.GitRef == "" && !repo.IsLocal() {
 			repo.GitRef = masterGitRef
 		}
 		repo.URL = trimUrl(repo.URL)
@@ -112,34 +137,41 @@ func RemoveRepository(name string) (RegistryModification, error) {
 	return removeFunc, nil
 }
 
+// UpdateRegistry applies the given modifications one after another to the stored registry, and writes the result in
+// a single transaction. If the registry has been modified concurrently in the meantime, the modifications are applied
+// again to the new registry, up to RegistryUpdateAttempts times.
 func UpdateRegistry(updates ...RegistryModification) error {
-	var registry RepoRegistry
-	ptrRegistry, err := Registry()
-	if err != nil {
-		return err
-	}
-	for _, update := range updates {
-		registry = update(*ptrRegistry)
-	}
-
 	store, err := GetStoreFromConfig()
 	if err != nil {
 		return err
 	}
-	if err := store.WriteRegistry(registry); err != nil {
-		return err
-	}
 
-	return nil
-}
+	for attempt := 1; ; attempt++ {
+		registry, rev, err := store.ReadRegistryRevision()
+		if err != nil {
+			return err
+		}
+		for _, update := range updates {
+			*registry = update(*registry)
+		}
 
-type Auth struct {
-	Basic string `json:"basic,omitempty"`
+		err = store.CompareAndWriteRegistry(*registry, rev)
+		if !goerrors.Is(err, errors.RegistryConflictError) {
+			return err
+		}
+		if attempt == RegistryUpdateAttempts {
+			return fmt.Errorf("%w: giving up after %d attempts", err, attempt)
+		}
+		// Back off randomly, so concurrent writers don't collide again
+		time.Sleep(time.Duration(rand.Intn(attempt*registryUpdateBackoff)) * time.Millisecond)
+	}
 }
 
 type Repository struct {
 	GitRepository
 	Name string `json:"name"`
+	// Verification requires the checked out commit to be signed, before any concept is read from the repository
+	Verification *Verification `json:"verification,omitempty"`
 }
 
 func safeDelete(path string) error {
@@ -154,89 +186,160 @@ func safeDelete(path string) error {
 // Clones the given repository, or, in case the repo already is checked
 // out, pulls the upstream changes.
 func maybeClone(r Repository, path string, pull bool) error {
-	reg, err := Registry()
-	if err != nil {
+	return maybeCloneWithProgress(r, path, pull, nil)
+}
+
+// maybeCloneWithProgress is maybeClone, writing the progress reported by the remote to progress (if not nil). In
+// offline mode, it never touches the network, and fails if the repository is not cached yet.
+func maybeCloneWithProgress(r Repository, path string, pull bool, progress io.Writer) error {
+	repo, err := git.PlainOpen(path)
+	if err != nil && err != git.ErrRepositoryNotExists {
 		return err
 	}
-	var auth *http.BasicAuth
-	if val, ok := reg.Auths[r.URL]; ok {
-		var pair AuthPair
-		b, err := base64.StdEncoding.DecodeString(val.Basic)
-		if err != nil {
-			return err
-		}
-		if err := json.Unmarshal(b, &pair); err != nil {
-			return err
-		}
-		auth = &http.BasicAuth{
-			Username: pair.Username,
-			Password: pair.Password,
+	if repo != nil && !pull {
+		return nil
+	}
+
+	if IsOffline() {
+		if repo == nil {
+			return fmt.Errorf("%w: '%s' (%s) has no cache at '%s', run 'kable repo update' while online (%v)",
+				errors.RepositoryCacheMissingError, r.Name, r.URL, path, errors.OfflineError)
 		}
+		return fmt.Errorf("unable to update '%s': %w", r.Name, errors.OfflineError)
 	}
 
-	repo, err := git.PlainOpen(path)
+	auth, err := AuthMethod(r.URL)
 	if err != nil {
-		if err == git.ErrRepositoryNotExists {
-			cloneopts := &git.CloneOptions{
-				URL:           r.URL,
-				ReferenceName: plumbing.ReferenceName(r.GitRef),
-				SingleBranch:  true,
-				Auth:          auth,
-			}
-
-			_, err = git.PlainClone(path, false, cloneopts)
-			if err != nil {
-				return err
-			}
-			return nil
-		} else {
-			return err
-		}
+		return err
+	}
+	ref, err := ParseGitRef(r.GitRef)
+	if err != nil {
+		return err
 	}
 
-	if pull {
-		wt, err := repo.Worktree()
-		if err != nil {
-			return err
-		}
+	if repo == nil {
+		err = cloneRef(r, ref, path, auth, progress)
+	} else {
+		err = pullRef(repo, ref, auth, progress)
+	}
+	if err != nil {
+		return err
+	}
+	return markUpdated(path)
+}
 
-		if err := wt.Pull(&git.PullOptions{
-			ReferenceName: plumbing.ReferenceName(r.GitRef),
-			SingleBranch:  true,
-			Auth:          auth,
-		}); err != nil && err != git.NoErrAlreadyUpToDate {
-			return err
-		}
+// pullRef brings the checkout of repo up to date with the given ref
+func pullRef(repo *git.Repository, ref *GitRef, auth transport.AuthMethod, progress io.Writer) error {
+	if ref.Kind != BranchGitRef {
+		return updateRef(repo, ref, auth, progress)
 	}
 
+	wt, err := repo.Worktree()
+	if err != nil {
+		return err
+	}
+	if err := wt.Pull(&git.PullOptions{
+		ReferenceName: plumbing.ReferenceName(ref.Value),
+		SingleBranch:  true,
+		Auth:          auth,
+		Progress:      progress,
+	}); err != nil && err != git.NoErrAlreadyUpToDate {
+		return err
+	}
 	return nil
 }
 
 func (r Repository) AbsolutePath() (string, error) {
-	path := computePath(r.URL)
-	if err := maybeClone(r, path, false); err != nil {
+	var path string
+	var err error
+	if r.IsLocal() {
+		if path, err = r.localPath(); err != nil {
+			return "", err
+		}
+	} else {
+		if path, err = r.cachePath(); err != nil {
+			return "", err
+		}
+		if err := maybeClone(r, path, false); err != nil {
+			return "", err
+		}
+	}
+	if err := r.verifyCheckout(path); err != nil {
 		return "", err
 	}
 	return path, nil
 }
 
-func computePath(url string) string {
-	a := strings.Split(trimUrl(url), "/")
-	return filepath.Join(CacheDir, a[len(a)-1])
+// HeadCommit returns the SHA of the currently checked out commit of the repository. For local repositories, that
+// are not part of a git repository, it is empty, unless they have been imported from a bundle.
+func (r Repository) HeadCommit() (string, error) {
+	path, err := r.AbsolutePath()
+	if err != nil {
+		return "", err
+	}
+	var repo *git.Repository
+	if r.IsLocal() {
+		if repo = openLocal(path); repo == nil {
+			return bundleCommit(r), nil
+		}
+	} else if repo, err = git.PlainOpen(path); err != nil {
+		return "", err
+	}
+	head, err := repo.Head()
+	if err != nil {
+		return "", err
+	}
+	return head.Hash().String(), nil
 }
 
-type AuthPair struct {
-	Username string `json:"username"`
-	Password string `json:"password"`
+// CheckedOutRef returns the checked out commit of the cached repository (or local directory), and the tags pointing
+// at it. If the repository is not cached yet, or the local directory is not part of a git repository, the commit is
+// empty.
+func (r Repository) CheckedOutRef() (string, []string, error) {
+	var repo *git.Repository
+	if r.IsLocal() {
+		path, err := r.localPath()
+		if err != nil {
+			return "", nil, err
+		}
+		if repo = openLocal(path); repo == nil {
+			return bundleCommit(r), nil, nil
+		}
+	} else {
+		path, err := r.cachePath()
+		if err != nil {
+			return "", nil, err
+		}
+		repo, err = git.PlainOpen(path)
+		if err == git.ErrRepositoryNotExists {
+			return "", nil, nil
+		}
+		if err != nil {
+			return "", nil, err
+		}
+	}
+	head, err := repo.Head()
+	if err != nil {
+		return "", nil, err
+	}
+	tags, err := headTags(repo)
+	if err != nil {
+		return "", nil, err
+	}
+	return head.Hash().String(), tags, nil
 }
 
 func (r Repository) RepoIndex() (*RepoIndex, error) {
-	ri := RepoIndex{}
 	path, err := r.AbsolutePath()
 	if err != nil {
 		return nil, err
 	}
+	return ReadRepoIndex(path)
+}
 
+// ReadRepoIndex reads the index (kable.json) of the repository directory at path
+func ReadRepoIndex(path string) (*RepoIndex, error) {
+	ri := RepoIndex{}
 	b, err := ioutil.ReadFile(filepath.Join(path, RepoIndexFileName))
 	if err != nil {
 		return nil, err
@@ -254,19 +357,6 @@ type GitRepository struct {
 	GitRef string `json:"gitRef"`
 }
 
-func UpdateRepositories() error {
-	repos, err := ListRepositories()
-	if err != nil {
-		return err
-	}
-	for _, repo := range repos {
-		if err := maybeClone(repo, computePath(repo.URL), true); err != nil {
-			return err
-		}
-	}
-	return nil
-}
-
 func ListRepositories() ([]Repository, error) {
 	r, err := Registry()
 	if err != nil {
@@ -275,23 +365,6 @@ func ListRepositories() ([]Repository, error) {
 	return r.Repositories.List(), nil
 }
 
-func TidyCache() error {
-	files, err := ioutil.ReadDir(CacheDir)
-	if err != nil {
-		return err
-	}
-
-	for _, file := range files {
-		_, err := GetRepository(file.Name())
-		if err != nil {
-			if err := os.RemoveAll(filepath.Join(CacheDir, file.Name())); err != nil {
-				return err
-			}
-		}
-	}
-	return nil
-}
-
 func GetRepository(name string) (Repository, error) {
 	r, err := Registry()
 	if err != nil {
@@ -308,31 +381,22 @@ func GetRepository(name string) (Repository, error) {
 type RepoIndex struct {
 	Version        int      `json:"version"`
 	ConceptEntries []string `json:"concepts"`
+	// Discover makes kable walk the repository for concepts, instead of relying on ConceptEntries
+	Discover bool `json:"discover,omitempty"`
+	// Include and Exclude are globs, filtering the concept paths found by discovery
+	Include []string `json:"include,omitempty"`
+	Exclude []string `json:"exclude,omitempty"`
+	// ConceptMeta caches the metadata of the concepts by path, as generated by 'kable repo index'
+	ConceptMeta map[string]json.RawMessage `json:"meta,omitempty"`
 }
 
-func StoreRepoAuth(url string, pair AuthPair) (RegistryModification, error) {
-	b, err := json.Marshal(pair)
-	if err != nil {
-		return nil, err
-	}
-	return func(registry RepoRegistry) RepoRegistry {
-		if registry.Auths == nil {
-			registry.Auths = Auths{}
-		}
-		registry.Auths[trimUrl(url)] = Auth{Basic: base64.StdEncoding.EncodeToString(b)}
-		return registry
-	}, nil
-}
-
-func RepoAuthExists(url string) (bool, error) {
-	reg, err := Registry()
+// WriteRepoIndex writes the given index as kable.json into the repository directory at path
+func WriteRepoIndex(path string, ri RepoIndex) error {
+	b, err := json.MarshalIndent(ri, "", "  ")
 	if err != nil {
-		return false, err
-	}
-	if _, ok := reg.Auths[trimUrl(url)]; ok {
-		return true, nil
+		return err
 	}
-	return false, nil
+	return ioutil.WriteFile(filepath.Join(path, RepoIndexFileName), append(b, '\n'), 0644)
 }
 
 func trimUrl(url string) string {
@@ -2,16 +2,22 @@ package repositories
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
 	"time"
 
 	"github.com/fatih/structs"
 	"github.com/google/logger"
 	"github.com/mitchellh/mapstructure"
 	"github.com/redradrat/kable/pkg/errors"
+	"github.com/redradrat/kable/pkg/filelock"
 	"github.com/spf13/viper"
 	clientv3 "go.etcd.io/etcd/client/v3"
 )
@@ -90,43 +96,124 @@ func EtcdStoreConfigMap(endpoints []string, timeout time.Duration) StoreConfigMa
 type Store interface {
 	WriteRegistry(registry RepoRegistry) error
 	ReadRegistry() (*RepoRegistry, error)
+	// ReadRegistryRevision reads the registry along with the revision it is stored at
+	ReadRegistryRevision() (*RepoRegistry, RegistryRevision, error)
+	// CompareAndWriteRegistry writes the registry, only if the stored registry is still at the given revision.
+	// Otherwise it fails with errors.RegistryConflictError.
+	CompareAndWriteRegistry(registry RepoRegistry, rev RegistryRevision) error
 }
 
+// RegistryRevision identifies the state of a stored registry. Its format is up to the store.
+type RegistryRevision string
+
 type LocalStore struct{}
 
 func (l LocalStore) WriteRegistry(registry RepoRegistry) error {
+	unlock, err := lockRegistryFile()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	return writeRegistryFile(registry)
+}
+
+// writeRegistryFile replaces the registry file atomically, so readers never see a partially written registry
+func writeRegistryFile(registry RepoRegistry) error {
 	b, err := json.MarshalIndent(registry, "", "  ")
 	if err != nil {
 		return err
 	}
-	if err := ioutil.WriteFile(RepoRegistryPath, b, 0644); err != nil {
+	tmp, err := ioutil.TempFile(filepath.Dir(RepoRegistryPath), filepath.Base(RepoRegistryPath)+".tmp-")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(b); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Rename(tmp.Name(), RepoRegistryPath); err != nil {
+		os.Remove(tmp.Name())
 		return err
 	}
 	return nil
 }
 
-func (l LocalStore) ReadRegistry() (*RepoRegistry, error) {
+// lockRegistryFile takes an exclusive lock on the lock file next to the registry file, and returns the function
+// releasing it
+func lockRegistryFile() (func(), error) {
+	return filelock.Lock(RepoRegistryPath + ".lock")
+}
+
+// ReadRegistryRevision reads the registry file, with the digest of its content as revision. Both are taken from the
+// same read, so the revision always matches the returned registry.
+func (l LocalStore) ReadRegistryRevision() (*RepoRegistry, RegistryRevision, error) {
 	file, err := ioutil.ReadFile(RepoRegistryPath)
 	if err != nil {
 		if os.IsNotExist(err) {
 			if err := l.WriteRegistry(RepoRegistry{}); err != nil {
-				return nil, err
+				return nil, "", err
 			}
 			file, err = ioutil.ReadFile(RepoRegistryPath)
 			if err != nil {
-				return nil, err
+				return nil, "", err
 			}
 		} else {
-			return nil, err
+			return nil, "", err
 		}
 	}
 
 	r := RepoRegistry{}
 	if err := json.Unmarshal(file, &r); err != nil {
-		return nil, err
+		return nil, "", err
 	}
 
-	return &r, nil
+	return &r, registryRevision(file), nil
+}
+
+// CompareAndWriteRegistry writes the registry file, while holding the lock on it, if its content has not changed
+// since the given revision has been read.
+func (l LocalStore) CompareAndWriteRegistry(registry RepoRegistry, rev RegistryRevision) error {
+	unlock, err := lockRegistryFile()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	current, err := registryFileRevision()
+	if err != nil {
+		return err
+	}
+	if current != rev {
+		return errors.RegistryConflictError
+	}
+	return writeRegistryFile(registry)
+}
+
+func registryFileRevision() (RegistryRevision, error) {
+	b, err := ioutil.ReadFile(RepoRegistryPath)
+	if err != nil && !os.IsNotExist(err) {
+		return "", err
+	}
+	return registryRevision(b), nil
+}
+
+func registryRevision(content []byte) RegistryRevision {
+	sum := sha256.Sum256(content)
+	return RegistryRevision(hex.EncodeToString(sum[:]))
+}
+
+func (l LocalStore) ReadRegistry() (*RepoRegistry, error) {
+	r, _, err := l.ReadRegistryRevision()
+	return r, err
 }
 
 type EtcdStore struct {
@@ -175,53 +262,143 @@ func (e EtcdStore) writeRegistry(registry RepoRegistry, c *clientv3.Client) erro
 	return nil
 }
 
+// CompareAndWriteRegistry writes the registry in an etcd transaction, that only succeeds if the registry key has not
+// been modified since the given revision.
+func (e EtcdStore) CompareAndWriteRegistry(registry RepoRegistry, rev RegistryRevision) error {
+	c, err := clientv3.New(e.Config)
+	if err != nil {
+		logger.Errorf("unable to create new etcd client: %v", err)
+		return err
+	}
+	defer func() {
+		err = c.Close()
+	}()
+
+	return e.compareAndWriteRegistry(registry, rev, c)
+}
+
+func (e EtcdStore) compareAndWriteRegistry(registry RepoRegistry, rev RegistryRevision, c *clientv3.Client) error {
+	modRevision, err := strconv.ParseInt(string(rev), 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid registry revision '%s': %w", rev, err)
+	}
+	marshalledRegistry, err := json.Marshal(registry)
+	if err != nil {
+		return err
+	}
+
+	// A ModRevision of 0 means the key does not exist yet
+	ctx, cancel := context.WithTimeout(context.Background(), e.Timeout)
+	resp, err := c.Txn(ctx).
+		If(clientv3.Compare(clientv3.ModRevision(EtcdRegistryKey), "=", modRevision)).
+		Then(clientv3.OpPut(EtcdRegistryKey, string(marshalledRegistry))).
+		Commit()
+	cancel()
+	if err != nil {
+		return err
+	}
+	if !resp.Succeeded {
+		logger.V(2).Info(fmt.Sprintf("Registry has been modified since revision %d", modRevision))
+		return errors.RegistryConflictError
+	}
+
+	logger.V(2).Info("Updated registry")
+	logger.V(3).Info(fmt.Sprintf("New Registry: %s", registry.String()))
+	return nil
+}
+
 func (e EtcdStore) ReadRegistry() (*RepoRegistry, error) {
+	r, _, err := e.ReadRegistryRevision()
+	return r, err
+}
+
+// ReadRegistryRevision reads the registry, with the ModRevision of its key as revision
+func (e EtcdStore) ReadRegistryRevision() (*RepoRegistry, RegistryRevision, error) {
 	c, err := clientv3.New(e.Config)
 	if err != nil {
 		logger.Errorf("unable to create new etcd client: %v", err)
-		return nil, err
+		return nil, "", err
 	}
 	defer func() {
 		err = c.Close()
 	}()
 
-	return e.readRegistry(c)
+	return e.readRegistryRevision(c)
 }
 
 func (e EtcdStore) readRegistry(c *clientv3.Client) (*RepoRegistry, error) {
+	r, _, err := e.readRegistryRevision(c)
+	return r, err
+}
+
+func (e EtcdStore) readRegistryRevision(c *clientv3.Client) (*RepoRegistry, RegistryRevision, error) {
 	out := RepoRegistry{}
+	var modRevision int64
 	ctx, cancel := context.WithTimeout(context.Background(), e.Timeout)
 	resp, err := c.Get(ctx, EtcdRegistryKey)
 	cancel()
 	if err != nil {
-		return nil, err
+		return nil, "", err
 	}
 	if len(resp.Kvs) > 1 {
 		logger.Errorf("detected multiple registries in store")
-		return nil, errors.MultipleRegistriesInStoreError
+		return nil, "", errors.MultipleRegistriesInStoreError
 	}
 	for _, ev := range resp.Kvs {
 		r := RepoRegistry{}
 		if err := json.Unmarshal(ev.Value, &r); err != nil {
 			logger.Errorf("unable to unmarshal registry from store: %v", err)
-			return nil, err
+			return nil, "", err
 		}
 		out = r
+		modRevision = ev.ModRevision
 	}
 
-	return &out, nil
+	return &out, RegistryRevision(strconv.FormatInt(modRevision, 10)), nil
 }
 
 var mockStoreBackend = map[string]RepoRegistry{}
+var mockStoreRevision int
+var mockStoreLock sync.Mutex
 
 type MockStore struct{}
 
 func (m MockStore) WriteRegistry(registry RepoRegistry) error {
+	mockStoreLock.Lock()
+	defer mockStoreLock.Unlock()
 	mockStoreBackend["registry"] = registry
+	mockStoreRevision++
 	return nil
 }
 
 func (m MockStore) ReadRegistry() (*RepoRegistry, error) {
-	reg := mockStoreBackend["registry"]
-	return &reg, nil
+	r, _, err := m.ReadRegistryRevision()
+	return r, err
+}
+
+// ReadRegistryRevision returns a copy of the mocked registry, so modifications never alter the stored registry in
+// place
+func (m MockStore) ReadRegistryRevision() (*RepoRegistry, RegistryRevision, error) {
+	mockStoreLock.Lock()
+	defer mockStoreLock.Unlock()
+	b, err := json.Marshal(mockStoreBackend["registry"])
+	if err != nil {
+		return nil, "", err
+	}
+	reg := RepoRegistry{}
+	if err := json.Unmarshal(b, &reg); err != nil {
+		return nil, "", err
+	}
+	return &reg, RegistryRevision(strconv.Itoa(mockStoreRevision)), nil
+}
+
+func (m MockStore) CompareAndWriteRegistry(registry RepoRegistry, rev RegistryRevision) error {
+	mockStoreLock.Lock()
+	defer mockStoreLock.Unlock()
+	if RegistryRevision(strconv.Itoa(mockStoreRevision)) != rev {
+		return errors.RegistryConflictError
+	}
+	mockStoreBackend["registry"] = registry
+	mockStoreRevision++
+	return nil
 }
@@ -1,10 +1,13 @@
 package repositories
 
 import (
+	goerrors "errors"
+	"io/ioutil"
 	"os"
 	"path/filepath"
 	"reflect"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -12,6 +15,8 @@ import (
 	clientv3 "go.etcd.io/etcd/client/v3"
 
 	"github.com/labstack/gommon/random"
+
+	"github.com/redradrat/kable/pkg/errors"
 )
 
 func TestGetRepository(t *testing.T) {
@@ -440,19 +445,30 @@ func TestUpdateRepositories(t *testing.T) {
 func Test_computePath(t *testing.T) {
 	type args struct {
 		url string
+		ref string
 	}
+	base := args{url: DemoHttpsUrl, ref: masterGitRef}
 	tests := []struct {
 		name string
 		args args
-		want string
+		same bool
 	}{
-		{name: "https", args: struct{ url string }{url: DemoHttpsUrl}, want: filepath.Join(CacheDir, "kable")},
-		{name: "ssh", args: struct{ url string }{url: DemoSshUrl}, want: filepath.Join(CacheDir, "kable")},
-	}
+		{name: "identical", args: base, same: true},
+		{name: "default ref", args: args{url: DemoHttpsUrl}, same: true},
+		{name: "spelling", args: args{url: "https://GitHub.com/redradrat/kable/", ref: masterGitRef}, same: true},
+		{name: "other ref", args: args{url: DemoHttpsUrl, ref: "refs/tags/v1.0.0"}},
+		{name: "other owner", args: args{url: "https://github.com/other/kable.git", ref: masterGitRef}},
+		{name: "ssh", args: args{url: DemoSshUrl, ref: masterGitRef}},
+	}
+	want := computePath(base.url, base.ref)
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if got := computePath(tt.args.url); got != tt.want {
-				t.Errorf("computePath() = %v, want %v", got, tt.want)
+			got := computePath(tt.args.url, tt.args.ref)
+			if (got == want) != tt.same {
+				t.Errorf("computePath() = %v, base %v, want same %v", got, want, tt.same)
+			}
+			if filepath.Dir(got) != CacheDir || !strings.HasPrefix(filepath.Base(got), "kable-") {
+				t.Errorf("computePath() = %v, want a 'kable-' entry in %v", got, CacheDir)
 			}
 		})
 	}
@@ -664,3 +680,86 @@ func TestEtcdStore_writeRegistry(t *testing.T) {
 		})
 	}
 }
+
+func TestUpdateRegistry_chained(t *testing.T) {
+	viper.Set(StoreKey, MockStoreConfigMap().Map())
+	defer viper.Set(StoreKey, nil)
+	defer delete(mockStoreBackend, "registry")
+
+	var mods []RegistryModification
+	for _, name := range []string{"first", "second", "third"} {
+		mod, err := AddRepository(Repository{Name: name, GitRepository: GitRepository{URL: DemoHttpsUrl}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		mods = append(mods, mod)
+	}
+	remove, err := RemoveRepository("second")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := UpdateRegistry(append(mods, remove)...); err != nil {
+		t.Fatal(err)
+	}
+
+	registry, err := Registry()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(registry.Repositories) != 2 || registry.Repositories["first"].Name == "" || registry.Repositories["third"].Name == "" {
+		t.Errorf("UpdateRegistry() repositories = %v, want first and third", registry.Repositories)
+	}
+}
+
+func TestLocalStore_CompareAndWriteRegistry(t *testing.T) {
+	dir, err := ioutil.TempDir("", "kable-registry")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	origPath := RepoRegistryPath
+	RepoRegistryPath = filepath.Join(dir, RegistryFileName)
+	defer func() { RepoRegistryPath = origPath }()
+	viper.Set(StoreKey, LocalStoreConfigMap().Map())
+	defer viper.Set(StoreKey, nil)
+
+	store := LocalStore{}
+	_, stale, err := store.ReadRegistryRevision()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Concurrent updates must not overwrite each other
+	names := []string{"a", "b", "c", "d", "e", "f", "g", "h"}
+	var wg sync.WaitGroup
+	errs := make(chan error, len(names))
+	for _, name := range names {
+		mod, err := AddRepository(Repository{Name: "repo-" + name, GitRepository: GitRepository{URL: DemoHttpsUrl}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- UpdateRegistry(mod)
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Errorf("UpdateRegistry() error = %v", err)
+		}
+	}
+	registry, err := store.ReadRegistry()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(registry.Repositories) != len(names) {
+		t.Errorf("UpdateRegistry() lost updates: %d repositories, want %d", len(registry.Repositories), len(names))
+	}
+
+	if err := store.CompareAndWriteRegistry(RepoRegistry{}, stale); !goerrors.Is(err, errors.RegistryConflictError) {
+		t.Errorf("CompareAndWriteRegistry() error = %v, want %v", err, errors.RegistryConflictError)
+	}
+}
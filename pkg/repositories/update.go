@@ -0,0 +1,214 @@
+package repositories
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/go-git/go-git/v5"
+)
+
+const DefaultUpdateConcurrency = 4
+
+type UpdateStatus string
+
+const (
+	UpdatedStatus  UpdateStatus = "updated"
+	UpToDateStatus UpdateStatus = "up-to-date"
+	SkippedStatus  UpdateStatus = "skipped"
+	FailedStatus   UpdateStatus = "failed"
+)
+
+type UpdateOpts struct {
+	Concurrency int
+	// Progress receives the progress reported by the remotes, line by line and prefixed with the repository name
+	Progress io.Writer
+}
+
+// UpdateResult is the outcome of updating a single repository.
+type UpdateResult struct {
+	Repository Repository
+	Status     UpdateStatus
+	Commit     string
+	Err        error
+}
+
+type UpdateReport struct {
+	Results []UpdateResult
+}
+
+// Failed returns the results of all repositories that could not be updated.
+func (ur UpdateReport) Failed() []UpdateResult {
+	var out []UpdateResult
+	for _, res := range ur.Results {
+		if res.Err != nil {
+			out = append(out, res)
+		}
+	}
+	return out
+}
+
+func UpdateRepositories() error {
+	report, err := UpdateRepositoriesWithOpts(UpdateOpts{})
+	if err != nil {
+		return err
+	}
+	if failed := report.Failed(); len(failed) != 0 {
+		return fmt.Errorf("unable to update '%s': %w", failed[0].Repository.Name, failed[0].Err)
+	}
+	return nil
+}
+
+// UpdateRepositoriesWithOpts clones or pulls all configured repositories. Repositories are updated concurrently, and
+// a failing repository does not stop the others.
+func UpdateRepositoriesWithOpts(opts UpdateOpts) (*UpdateReport, error) {
+	repos, err := ListRepositories()
+	if err != nil {
+		return nil, err
+	}
+	if opts.Concurrency < 1 {
+		opts.Concurrency = DefaultUpdateConcurrency
+	}
+
+	// Entries of the same remote and ref share a checkout, which must be updated only once, and not concurrently
+	var groups [][]int
+	byPath := map[string]int{}
+	for i, repo := range repos {
+		path, err := repo.cachePath()
+		if repo.IsLocal() || err != nil {
+			groups = append(groups, []int{i})
+			continue
+		}
+		if g, ok := byPath[path]; ok {
+			groups[g] = append(groups[g], i)
+			continue
+		}
+		byPath[path] = len(groups)
+		groups = append(groups, []int{i})
+	}
+
+	report := &UpdateReport{Results: make([]UpdateResult, len(repos))}
+	outMu := &sync.Mutex{}
+	jobs := make(chan []int)
+	wg := sync.WaitGroup{}
+	for w := 0; w < opts.Concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for group := range jobs {
+				var names []string
+				var groupRepos []Repository
+				for _, i := range group {
+					names = append(names, repos[i].Name)
+					groupRepos = append(groupRepos, repos[i])
+				}
+				var progress io.Writer
+				if opts.Progress != nil {
+					progress = &progressWriter{prefix: "[" + strings.Join(names, ", ") + "] ", out: opts.Progress, mu: outMu}
+				}
+				for j, res := range updateCheckout(groupRepos, progress) {
+					report.Results[group[j]] = res
+				}
+			}
+		}()
+	}
+	for _, group := range groups {
+		jobs <- group
+	}
+	close(jobs)
+	wg.Wait()
+
+	return report, nil
+}
+
+// updateCheckout clones or pulls the checkout shared by the given repositories once, and returns the result for each
+// of them. The repositories have to share the same cache path, or be a single local repository.
+func updateCheckout(repos []Repository, progress io.Writer) []UpdateResult {
+	results := make([]UpdateResult, len(repos))
+	fail := func(err error) []UpdateResult {
+		for i, repo := range repos {
+			results[i] = UpdateResult{Repository: repo, Status: FailedStatus, Err: err}
+		}
+		return results
+	}
+
+	// Local repositories are read straight from their directory
+	if repos[0].IsLocal() {
+		res := UpdateResult{Repository: repos[0], Status: SkippedStatus}
+		res.Commit, res.Err = repos[0].HeadCommit()
+		if res.Err != nil {
+			res.Status = FailedStatus
+		}
+		return []UpdateResult{res}
+	}
+
+	path, err := repos[0].cachePath()
+	if err != nil {
+		return fail(err)
+	}
+	before := headCommit(path)
+	if err := maybeCloneWithProgress(repos[0], path, true, progress); err != nil {
+		return fail(err)
+	}
+	commit := headCommit(path)
+	for i, repo := range repos {
+		res := UpdateResult{Repository: repo, Status: UpdatedStatus, Commit: commit}
+		if commit == before {
+			res.Status = UpToDateStatus
+		}
+		// Verification is configured per repository, so it is checked for every entry
+		if err := repo.verifyCheckout(path); err != nil {
+			res.Status, res.Err = FailedStatus, err
+		}
+		results[i] = res
+	}
+	return results
+}
+
+// headCommit returns the checked out commit of the git repository at path, or an empty string if there is none
+func headCommit(path string) string {
+	repo, err := git.PlainOpen(path)
+	if err != nil {
+		return ""
+	}
+	head, err := repo.Head()
+	if err != nil {
+		return ""
+	}
+	return head.Hash().String()
+}
+
+// progressWriter forwards the progress of a remote to out, prefixing every line. Intermediate updates of a line
+// (terminated by '\r') are dropped, as they can't be displayed sensibly for concurrent updates.
+type progressWriter struct {
+	prefix string
+	out    io.Writer
+	mu     *sync.Mutex
+	buf    bytes.Buffer
+}
+
+func (pw *progressWriter) Write(p []byte) (int, error) {
+	for _, b := range p {
+		switch b {
+		case '\r':
+			pw.buf.Reset()
+		case '\n':
+			line := strings.TrimSpace(pw.buf.String())
+			pw.buf.Reset()
+			if line == "" {
+				continue
+			}
+			pw.mu.Lock()
+			_, err := fmt.Fprintf(pw.out, "%s%s\n", pw.prefix, line)
+			pw.mu.Unlock()
+			if err != nil {
+				return 0, err
+			}
+		default:
+			pw.buf.WriteByte(b)
+		}
+	}
+	return len(p), nil
+}
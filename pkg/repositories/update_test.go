@@ -0,0 +1,95 @@
+package repositories
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/spf13/viper"
+)
+
+func TestUpdateRepositoriesWithOpts(t *testing.T) {
+	viper.Set(StoreKey, MockStoreConfigMap().Map())
+	defer viper.Set(StoreKey, nil)
+	defer delete(mockStoreBackend, "registry")
+	dir, err := ioutil.TempDir("", "kable-update")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	origCacheDir := CacheDir
+	CacheDir = filepath.Join(dir, "cache")
+	defer func() { CacheDir = origCacheDir }()
+
+	src := filepath.Join(dir, "concepts")
+	upstream, err := git.PlainInit(src, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	commitAndTag(t, upstream, src, "v1.0.0")
+
+	var mods []RegistryModification
+	for _, repo := range []Repository{
+		{Name: "good", GitRepository: GitRepository{URL: src}},
+		{Name: "broken", GitRepository: GitRepository{URL: filepath.Join(dir, "missing")}},
+		{Name: "local", GitRepository: GitRepository{URL: LocalURLScheme + src}},
+		// Shares the checkout of 'good', so it must not be updated concurrently with it
+		{Name: "good-again", GitRepository: GitRepository{URL: src + "/"}},
+	} {
+		mod, err := AddRepository(repo)
+		if err != nil {
+			t.Fatal(err)
+		}
+		mods = append(mods, mod)
+	}
+	for _, mod := range mods {
+		if err := UpdateRegistry(mod); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name string
+		want map[string]UpdateStatus
+	}{
+		{name: "clone", want: map[string]UpdateStatus{"good": UpdatedStatus, "good-again": UpdatedStatus, "broken": FailedStatus, "local": SkippedStatus}},
+		{name: "pull", want: map[string]UpdateStatus{"good": UpToDateStatus, "good-again": UpToDateStatus, "broken": FailedStatus, "local": SkippedStatus}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report, err := UpdateRepositoriesWithOpts(UpdateOpts{Concurrency: 2})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(report.Results) != len(tt.want) {
+				t.Fatalf("UpdateRepositoriesWithOpts() got %d results, want %d", len(report.Results), len(tt.want))
+			}
+			for _, res := range report.Results {
+				if res.Status != tt.want[res.Repository.Name] {
+					t.Errorf("UpdateRepositoriesWithOpts() status of '%s' = %v (%v), want %v", res.Repository.Name, res.Status, res.Err, tt.want[res.Repository.Name])
+				}
+			}
+			if len(report.Failed()) != 1 {
+				t.Errorf("UpdateRepositoriesWithOpts() failed = %v, want only 'broken'", report.Failed())
+			}
+		})
+	}
+}
+
+func Test_progressWriter(t *testing.T) {
+	out := &bytes.Buffer{}
+	pw := &progressWriter{prefix: "[repo] ", out: out, mu: &sync.Mutex{}}
+	for _, chunk := range []string{"Counting objects:  50% (1/2)\r", "Counting objects: 100% (2/2), done.\n", "Total 2\n\n"} {
+		if _, err := pw.Write([]byte(chunk)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want := "[repo] Counting objects: 100% (2/2), done.\n[repo] Total 2\n"
+	if out.String() != want {
+		t.Errorf("progressWriter wrote %q, want %q", out.String(), want)
+	}
+}
@@ -0,0 +1,125 @@
+package repositories
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"golang.org/x/crypto/openpgp"
+
+	"github.com/redradrat/kable/pkg/errors"
+)
+
+type VerificationMode string
+
+const (
+	// CommitVerificationMode requires the checked out commit itself to be signed
+	CommitVerificationMode VerificationMode = "commit"
+	// TagVerificationMode requires a signed, annotated tag pointing at the checked out commit
+	TagVerificationMode VerificationMode = "tag"
+)
+
+// Verification requires the checked out commit of a repository to be signed by one of the trusted OpenPGP keys.
+type Verification struct {
+	Mode VerificationMode `json:"mode"`
+	// TrustedKeys are armored OpenPGP public keys
+	TrustedKeys []string `json:"trustedKeys"`
+}
+
+func (v Verification) Validate() error {
+	if v.Mode != CommitVerificationMode && v.Mode != TagVerificationMode {
+		return fmt.Errorf("%w: unknown mode '%s' (expected '%s' or '%s')", errors.InvalidVerificationError, v.Mode, CommitVerificationMode, TagVerificationMode)
+	}
+	if len(v.TrustedKeys) == 0 {
+		return fmt.Errorf("%w: no trusted keys given", errors.InvalidVerificationError)
+	}
+	for i, key := range v.TrustedKeys {
+		if _, err := openpgp.ReadArmoredKeyRing(strings.NewReader(key)); err != nil {
+			return fmt.Errorf("%w: trusted key %d is no armored OpenPGP public key: %v", errors.InvalidVerificationError, i+1, err)
+		}
+	}
+	return nil
+}
+
+// verifyCheckout verifies the signature of the commit checked out at path, if the repository requires it
+func (r Repository) verifyCheckout(path string) error {
+	if r.Verification == nil {
+		return nil
+	}
+	repo := openLocal(path)
+	if repo == nil {
+		return fmt.Errorf("%w: '%s' is not a git repository", errors.SignatureVerificationError, r.Name)
+	}
+	head, err := repo.Head()
+	if err != nil {
+		return err
+	}
+
+	switch r.Verification.Mode {
+	case CommitVerificationMode:
+		commit, err := repo.CommitObject(head.Hash())
+		if err != nil {
+			return err
+		}
+		if commit.PGPSignature == "" {
+			return fmt.Errorf("%w: commit %s of '%s' is not signed", errors.SignatureVerificationError, shortHash(head.Hash()), r.Name)
+		}
+		if !r.Verification.trusted(commit.Verify) {
+			return fmt.Errorf("%w: commit %s of '%s' is not signed by a trusted key", errors.SignatureVerificationError, shortHash(head.Hash()), r.Name)
+		}
+		return nil
+	case TagVerificationMode:
+		signed, err := r.Verification.signedTag(repo, head.Hash())
+		if err != nil {
+			return err
+		}
+		if !signed {
+			return fmt.Errorf("%w: no tag signed by a trusted key points at commit %s of '%s'", errors.SignatureVerificationError, shortHash(head.Hash()), r.Name)
+		}
+		return nil
+	default:
+		return r.Verification.Validate()
+	}
+}
+
+// signedTag returns whether an annotated tag, signed by a trusted key, points at the given commit
+func (v Verification) signedTag(repo *git.Repository, commit plumbing.Hash) (bool, error) {
+	tags, err := repo.Tags()
+	if err != nil {
+		return false, err
+	}
+	defer tags.Close()
+	signed := false
+	err = tags.ForEach(func(ref *plumbing.Reference) error {
+		tag, err := repo.TagObject(ref.Hash())
+		if err != nil {
+			// Lightweight tags can't be signed
+			return nil
+		}
+		target, err := tag.Commit()
+		if err != nil || target.Hash != commit || tag.PGPSignature == "" {
+			return nil
+		}
+		if v.trusted(tag.Verify) {
+			signed = true
+		}
+		return nil
+	})
+	return signed, err
+}
+
+// trusted returns whether verify succeeds with any of the trusted keys. Each key is checked on its own, as go-git
+// only reads a single armored keyring.
+func (v Verification) trusted(verify func(armoredKeyRing string) (*openpgp.Entity, error)) bool {
+	for _, key := range v.TrustedKeys {
+		if _, err := verify(key); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+func shortHash(h plumbing.Hash) string {
+	return h.String()[:7]
+}
@@ -0,0 +1,134 @@
+package repositories
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"golang.org/x/crypto/openpgp"
+	"golang.org/x/crypto/openpgp/armor"
+)
+
+func testEntity(t *testing.T, name string) (*openpgp.Entity, string) {
+	entity, err := openpgp.NewEntity(name, "", name+"@kab.le", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	buf := &bytes.Buffer{}
+	w, err := armor.Encode(buf, openpgp.PublicKeyType, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := entity.Serialize(w); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return entity, buf.String()
+}
+
+func TestVerification_Validate(t *testing.T) {
+	_, key := testEntity(t, "trusted")
+	tests := []struct {
+		name    string
+		v       Verification
+		wantErr bool
+	}{
+		{name: "valid", v: Verification{Mode: CommitVerificationMode, TrustedKeys: []string{key}}},
+		{name: "unknown mode", v: Verification{Mode: "branch", TrustedKeys: []string{key}}, wantErr: true},
+		{name: "no keys", v: Verification{Mode: TagVerificationMode}, wantErr: true},
+		{name: "invalid key", v: Verification{Mode: CommitVerificationMode, TrustedKeys: []string{"not a key"}}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.v.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRepository_verifyCheckout(t *testing.T) {
+	dir, err := ioutil.TempDir("", "kable-verify")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	trusted, trustedKey := testEntity(t, "trusted")
+	untrusted, _ := testEntity(t, "untrusted")
+
+	repo, err := git.PlainInit(dir, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wt, err := repo.Worktree()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sig := &object.Signature{Name: "test", Email: "test@kab.le", When: time.Now()}
+	commit := func(msg string, key *openpgp.Entity) plumbing.Hash {
+		if err := ioutil.WriteFile(filepath.Join(dir, RepoIndexFileName), []byte(msg), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := wt.Add(RepoIndexFileName); err != nil {
+			t.Fatal(err)
+		}
+		hash, err := wt.Commit(msg, &git.CommitOptions{Author: sig, SignKey: key})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return hash
+	}
+	tag := func(name string, hash plumbing.Hash, opts *git.CreateTagOptions) {
+		if _, err := repo.CreateTag(name, hash, opts); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	unsigned := commit("unsigned", nil)
+	signedTrusted := commit("trusted", trusted)
+	signedUntrusted := commit("untrusted", untrusted)
+	trustedTag := commit("trusted tag", nil)
+	tag("v1.0.0", trustedTag, &git.CreateTagOptions{Tagger: sig, Message: "v1.0.0", SignKey: trusted})
+	untrustedTag := commit("untrusted tag", nil)
+	tag("v1.1.0", untrustedTag, &git.CreateTagOptions{Tagger: sig, Message: "v1.1.0", SignKey: untrusted})
+	lightweightTag := commit("lightweight tag", trusted)
+	tag("v1.2.0", lightweightTag, nil)
+
+	tests := []struct {
+		name    string
+		mode    VerificationMode
+		head    plumbing.Hash
+		wantErr bool
+	}{
+		{name: "no verification", head: unsigned},
+		{name: "unsigned commit", mode: CommitVerificationMode, head: unsigned, wantErr: true},
+		{name: "trusted commit", mode: CommitVerificationMode, head: signedTrusted},
+		{name: "untrusted commit", mode: CommitVerificationMode, head: signedUntrusted, wantErr: true},
+		{name: "trusted tag", mode: TagVerificationMode, head: trustedTag},
+		{name: "untrusted tag", mode: TagVerificationMode, head: untrustedTag, wantErr: true},
+		{name: "lightweight tag", mode: TagVerificationMode, head: lightweightTag, wantErr: true},
+		{name: "trusted commit without tag", mode: TagVerificationMode, head: signedTrusted, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := wt.Checkout(&git.CheckoutOptions{Hash: tt.head, Force: true}); err != nil {
+				t.Fatal(err)
+			}
+			r := Repository{Name: "test"}
+			if tt.mode != "" {
+				r.Verification = &Verification{Mode: tt.mode, TrustedKeys: []string{trustedKey}}
+			}
+			if err := r.verifyCheckout(dir); (err != nil) != tt.wantErr {
+				t.Errorf("verifyCheckout() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}